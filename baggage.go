@@ -0,0 +1,171 @@
+package opentracing
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrBaggageKeyInvalid occurs when a baggage item key does not match the
+	// format documented on Span.SetBaggageItem().
+	ErrBaggageKeyInvalid = errors.New("opentracing: Invalid baggage item key")
+
+	// ErrBaggageKeyNotAllowed occurs when a BaggagePolicy has an AllowedKeys
+	// list and the baggage item key is not on it.
+	ErrBaggageKeyNotAllowed = errors.New("opentracing: Baggage item key not allowed")
+
+	// ErrBaggageKeyTooLong occurs when a baggage item key exceeds
+	// BaggagePolicy.MaxKeyBytes.
+	ErrBaggageKeyTooLong = errors.New("opentracing: Baggage item key too long")
+
+	// ErrBaggageValueTooLong occurs when a baggage item value exceeds
+	// BaggagePolicy.MaxValueBytes.
+	ErrBaggageValueTooLong = errors.New("opentracing: Baggage item value too long")
+
+	// ErrBaggageTooManyItems occurs when adding a baggage item would exceed
+	// BaggagePolicy.MaxItems.
+	ErrBaggageTooManyItems = errors.New("opentracing: Too many baggage items")
+
+	// ErrBaggageTooLarge occurs when adding a baggage item would exceed
+	// BaggagePolicy.MaxTotalBytes.
+	ErrBaggageTooLarge = errors.New("opentracing: Baggage too large")
+)
+
+// BaggageDrop describes a baggage item that was rejected by a BaggagePolicy.
+type BaggageDrop struct {
+	Key   string
+	Value string
+
+	// Err is one of the ErrBaggage* errors, or the error returned by a
+	// BaggagePolicy.Validators entry.
+	Err error
+}
+
+// BaggagePolicy bounds the size and cardinality of the baggage carried by a
+// Span. Tracer implementations should apply it both in
+// Span.SetBaggageItem() (see Admit) and to baggage decoded by Tracer.Join()
+// (see Filter).
+//
+// All limits are expressed in bytes of the (canonicalized) key or value; a
+// zero limit means "unlimited". A nil *BaggagePolicy admits everything.
+type BaggagePolicy struct {
+	// MaxItems limits the number of distinct baggage keys on a Span.
+	MaxItems int
+
+	// MaxKeyBytes limits the length of each baggage key.
+	MaxKeyBytes int
+
+	// MaxValueBytes limits the length of each baggage value.
+	MaxValueBytes int
+
+	// MaxTotalBytes limits the sum of the lengths of all keys and values on
+	// a Span.
+	MaxTotalBytes int
+
+	// AllowedKeys, if non-nil, is the exhaustive list of baggage keys that
+	// may be set. Keys are compared case-insensitively.
+	AllowedKeys []string
+
+	// Validators maps a (lowercase) baggage key to a function that vets its
+	// value. A non-nil error rejects the item.
+	Validators map[string]func(value string) error
+
+	// OnDrop, if non-nil, is called for every item rejected by Admit or
+	// Filter.
+	OnDrop func(drop BaggageDrop)
+}
+
+// Check returns nil if and only if `key` and `value` may be added to
+// `baggage`, the current baggage of a Span. If `key` is already present in
+// `baggage`, the existing item is considered replaced.
+//
+// Check does not call OnDrop; see Admit.
+func (p *BaggagePolicy) Check(baggage map[string]string, key, value string) error {
+	if p == nil {
+		return nil
+	}
+	canonicalKey, ok := CanonicalizeBaggageKey(key)
+	if !ok {
+		return ErrBaggageKeyInvalid
+	}
+	if p.AllowedKeys != nil && !p.allowed(canonicalKey) {
+		return ErrBaggageKeyNotAllowed
+	}
+	if p.MaxKeyBytes > 0 && len(key) > p.MaxKeyBytes {
+		return ErrBaggageKeyTooLong
+	}
+	if p.MaxValueBytes > 0 && len(value) > p.MaxValueBytes {
+		return ErrBaggageValueTooLong
+	}
+	items, total := 0, 0
+	for k, v := range baggage {
+		if k == key {
+			continue
+		}
+		items++
+		total += len(k) + len(v)
+	}
+	if p.MaxItems > 0 && items+1 > p.MaxItems {
+		return ErrBaggageTooManyItems
+	}
+	if p.MaxTotalBytes > 0 && total+len(key)+len(value) > p.MaxTotalBytes {
+		return ErrBaggageTooLarge
+	}
+	if validate := p.Validators[canonicalKey]; validate != nil {
+		if err := validate(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Admit is like Check but reports rejected items via OnDrop. It returns true
+// if the item may be added.
+func (p *BaggagePolicy) Admit(baggage map[string]string, key, value string) bool {
+	err := p.Check(baggage, key, value)
+	if err == nil {
+		return true
+	}
+	if p.OnDrop != nil {
+		p.OnDrop(BaggageDrop{Key: key, Value: value, Err: err})
+	}
+	return false
+}
+
+// Filter returns a copy of `baggage` holding only the items admitted by the
+// policy, reporting the others via OnDrop. Items are considered in
+// lexicographic key order so that the outcome is deterministic when a limit
+// is reached.
+//
+// Tracer implementations should call Filter on the baggage decoded by
+// Tracer.Join().
+func (p *BaggagePolicy) Filter(baggage map[string]string) map[string]string {
+	rval := make(map[string]string, len(baggage))
+	if p == nil {
+		for k, v := range baggage {
+			rval[k] = v
+		}
+		return rval
+	}
+	keys := make([]string, 0, len(baggage))
+	for k := range baggage {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if p.Admit(rval, k, baggage[k]) {
+			rval[k] = baggage[k]
+		}
+	}
+	return rval
+}
+
+func (p *BaggagePolicy) allowed(canonicalKey string) bool {
+	for _, k := range p.AllowedKeys {
+		if strings.ToLower(k) == canonicalKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package opentracing
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBaggagePolicyCheck(t *testing.T) {
+	errNotNumeric := errors.New("not numeric")
+	policy := &BaggagePolicy{
+		MaxItems:      2,
+		MaxKeyBytes:   8,
+		MaxValueBytes: 16,
+		MaxTotalBytes: 24,
+		Validators: map[string]func(string) error{
+			"tenant": func(v string) error {
+				if strings.Trim(v, "0123456789") != "" {
+					return errNotNumeric
+				}
+				return nil
+			},
+		},
+	}
+	existing := map[string]string{"user": "alice"}
+
+	cases := []struct {
+		key, value string
+		expected   error
+	}{
+		{"region", "us-east", nil},
+		{"user", "bob", nil}, // replaces the existing item
+		{"bad_key", "x", ErrBaggageKeyInvalid},
+		{"toolongkey", "x", ErrBaggageKeyTooLong},
+		{"region", strings.Repeat("x", 17), ErrBaggageValueTooLong},
+		{"region", strings.Repeat("x", 16), ErrBaggageTooLarge},
+		{"tenant", "abc", errNotNumeric},
+		{"tenant", "42", nil},
+	}
+	for _, c := range cases {
+		if err := policy.Check(existing, c.key, c.value); err != c.expected {
+			t.Errorf("Check(%q, %q) = %v, expected %v", c.key, c.value, err, c.expected)
+		}
+	}
+
+	full := map[string]string{"a": "1", "b": "2"}
+	if err := policy.Check(full, "c", "3"); err != ErrBaggageTooManyItems {
+		t.Errorf("Expected ErrBaggageTooManyItems, got %v", err)
+	}
+}
+
+func TestBaggagePolicyAllowedKeys(t *testing.T) {
+	policy := &BaggagePolicy{AllowedKeys: []string{"Request-ID"}}
+	if err := policy.Check(nil, "request-id", "1"); err != nil {
+		t.Errorf("Expected allowed key to pass, got %v", err)
+	}
+	if err := policy.Check(nil, "other", "1"); err != ErrBaggageKeyNotAllowed {
+		t.Errorf("Expected ErrBaggageKeyNotAllowed, got %v", err)
+	}
+}
+
+func TestBaggagePolicyFilter(t *testing.T) {
+	var drops []BaggageDrop
+	policy := &BaggagePolicy{
+		MaxItems:      2,
+		MaxValueBytes: 4,
+		OnDrop:        func(d BaggageDrop) { drops = append(drops, d) },
+	}
+	filtered := policy.Filter(map[string]string{
+		"a": "1",
+		"b": "too long",
+		"c": "3",
+		"d": "4",
+	})
+	if expected := map[string]string{"a": "1", "c": "3"}; !reflect.DeepEqual(expected, filtered) {
+		t.Errorf("Filter() = %v, expected %v", filtered, expected)
+	}
+	expectedDrops := []BaggageDrop{
+		{Key: "b", Value: "too long", Err: ErrBaggageValueTooLong},
+		{Key: "d", Value: "4", Err: ErrBaggageTooManyItems},
+	}
+	if !reflect.DeepEqual(expectedDrops, drops) {
+		t.Errorf("Dropped %v, expected %v", drops, expectedDrops)
+	}
+}
+
+func TestNilBaggagePolicy(t *testing.T) {
+	var policy *BaggagePolicy
+	if !policy.Admit(nil, "anything", strings.Repeat("x", 1<<16)) {
+		t.Error("Expected a nil policy to admit everything")
+	}
+	baggage := map[string]string{"k": "v"}
+	if filtered := policy.Filter(baggage); !reflect.DeepEqual(baggage, filtered) {
+		t.Errorf("Filter() = %v, expected %v", filtered, baggage)
+	}
+}
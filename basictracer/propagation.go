@@ -0,0 +1,208 @@
+package basictracer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+	"github.com/opentracing/opentracing-go/sampling"
+)
+
+const (
+	prefixTracerState = "ot-tracer-"
+	prefixBaggage     = "ot-baggage-"
+
+	fieldNameTraceID = prefixTracerState + "traceid"
+	fieldNameSpanID  = prefixTracerState + "spanid"
+	fieldNameSampled = sampling.SampledTextMapKey
+)
+
+func injectTextMap(ctx recorder.SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	writer.Set(fieldNameTraceID, ctx.TraceID.String())
+	writer.Set(fieldNameSpanID, strconv.FormatUint(ctx.SpanID, 16))
+	if err := sampling.InjectDecision(sampling.Decision{Sampled: ctx.Sampled}, opentracing.TextMap, writer); err != nil {
+		return err
+	}
+	for k, v := range ctx.Baggage {
+		writer.Set(prefixBaggage+k, v)
+	}
+	return nil
+}
+
+func joinTextMap(carrier interface{}) (recorder.SpanContext, error) {
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return recorder.SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+	ctx := recorder.SpanContext{Sampled: true}
+	requiredFieldCount := 0
+	err := reader.ForeachKey(func(k, v string) error {
+		var err error
+		switch lowercaseKey := strings.ToLower(k); lowercaseKey {
+		case fieldNameTraceID:
+			ctx.TraceID, err = parseTraceID(v)
+			requiredFieldCount++
+		case fieldNameSpanID:
+			ctx.SpanID, err = strconv.ParseUint(v, 16, 64)
+			requiredFieldCount++
+		case fieldNameSampled:
+			ctx.Sampled, err = strconv.ParseBool(v)
+		default:
+			if strings.HasPrefix(lowercaseKey, prefixBaggage) {
+				if ctx.Baggage == nil {
+					ctx.Baggage = map[string]string{}
+				}
+				ctx.Baggage[strings.TrimPrefix(lowercaseKey, prefixBaggage)] = v
+			}
+		}
+		if err != nil {
+			return opentracing.ErrTraceCorrupted
+		}
+		return nil
+	})
+	if err != nil {
+		return recorder.SpanContext{}, err
+	}
+	switch requiredFieldCount {
+	case 0:
+		return recorder.SpanContext{}, opentracing.ErrTraceNotFound
+	case 2:
+		return ctx, nil
+	}
+	return recorder.SpanContext{}, opentracing.ErrTraceCorrupted
+}
+
+func parseTraceID(s string) (recorder.TraceID, error) {
+	var id recorder.TraceID
+	var err error
+	if len(s) > 16 {
+		if len(s) > 32 {
+			return id, opentracing.ErrTraceCorrupted
+		}
+		if id.High, err = strconv.ParseUint(s[:len(s)-16], 16, 64); err != nil {
+			return id, err
+		}
+		s = s[len(s)-16:]
+	}
+	id.Low, err = strconv.ParseUint(s, 16, 64)
+	return id, err
+}
+
+// The Binary format is, in network byte order:
+//
+//	version     uint8 (binaryFormatVersion)
+//	flags       uint8 (bit 0: sampled)
+//	trace ID    uint64 (high), uint64 (low)
+//	span ID     uint64
+//	baggage     uint32 item count, then for each item a uint32 length
+//	            followed by the key and a uint32 length followed by the
+//	            value
+const (
+	binaryFormatVersion = 1
+	binaryFlagSampled   = 1 << 0
+
+	// maxBinaryBaggageBytes guards against allocating huge buffers for
+	// corrupted input.
+	maxBinaryBaggageBytes = 1 << 20
+)
+
+type binaryHeader struct {
+	Version     uint8
+	Flags       uint8
+	TraceIDHigh uint64
+	TraceIDLow  uint64
+	SpanID      uint64
+	BaggageLen  uint32
+}
+
+func injectBinary(ctx recorder.SpanContext, carrier interface{}) error {
+	writer, ok := carrier.(io.Writer)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	header := binaryHeader{
+		Version:     binaryFormatVersion,
+		TraceIDHigh: ctx.TraceID.High,
+		TraceIDLow:  ctx.TraceID.Low,
+		SpanID:      ctx.SpanID,
+		BaggageLen:  uint32(len(ctx.Baggage)),
+	}
+	if ctx.Sampled {
+		header.Flags |= binaryFlagSampled
+	}
+	buf := &bytes.Buffer{}
+	binary.Write(buf, binary.BigEndian, &header)
+	for k, v := range ctx.Baggage {
+		writeBinaryString(buf, k)
+		writeBinaryString(buf, v)
+	}
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
+
+func writeBinaryString(buf *bytes.Buffer, s string) {
+	binary.Write(buf, binary.BigEndian, uint32(len(s)))
+	buf.WriteString(s)
+}
+
+func joinBinary(carrier interface{}) (recorder.SpanContext, error) {
+	reader, ok := carrier.(io.Reader)
+	if !ok {
+		return recorder.SpanContext{}, opentracing.ErrInvalidCarrier
+	}
+	var header binaryHeader
+	if err := binary.Read(reader, binary.BigEndian, &header); err != nil {
+		if err == io.EOF {
+			return recorder.SpanContext{}, opentracing.ErrTraceNotFound
+		}
+		return recorder.SpanContext{}, opentracing.ErrTraceCorrupted
+	}
+	if header.Version != binaryFormatVersion || header.SpanID == 0 {
+		return recorder.SpanContext{}, opentracing.ErrTraceCorrupted
+	}
+	ctx := recorder.SpanContext{
+		TraceID: recorder.TraceID{High: header.TraceIDHigh, Low: header.TraceIDLow},
+		SpanID:  header.SpanID,
+		Sampled: header.Flags&binaryFlagSampled != 0,
+	}
+	remaining := maxBinaryBaggageBytes
+	for i := uint32(0); i < header.BaggageLen; i++ {
+		k, err := readBinaryString(reader, &remaining)
+		if err != nil {
+			return recorder.SpanContext{}, err
+		}
+		v, err := readBinaryString(reader, &remaining)
+		if err != nil {
+			return recorder.SpanContext{}, err
+		}
+		if ctx.Baggage == nil {
+			ctx.Baggage = map[string]string{}
+		}
+		ctx.Baggage[k] = v
+	}
+	return ctx, nil
+}
+
+func readBinaryString(reader io.Reader, remaining *int) (string, error) {
+	var length uint32
+	if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+		return "", opentracing.ErrTraceCorrupted
+	}
+	if int64(length) > int64(*remaining) {
+		return "", opentracing.ErrTraceCorrupted
+	}
+	*remaining -= int(length)
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(reader, buf); err != nil {
+		return "", opentracing.ErrTraceCorrupted
+	}
+	return string(buf), nil
+}
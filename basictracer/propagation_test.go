@@ -0,0 +1,84 @@
+package basictracer
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func TestPropagation(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Recorder = recorder.NewInMemoryRecorder()
+	opts.TraceID128Bit = true
+	tracer := NewWithOptions(opts)
+
+	sp := tracer.StartSpan("x")
+	sp.SetBaggageItem("Checked", "true")
+	spCtx := sp.(Span).Context()
+
+	textCarrier := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+	binaryCarrier := &bytes.Buffer{}
+	tests := []struct {
+		format, carrier interface{}
+	}{
+		{opentracing.TextMap, textCarrier},
+		{opentracing.Binary, binaryCarrier},
+	}
+	for _, test := range tests {
+		if err := tracer.Inject(sp, test.format, test.carrier); err != nil {
+			t.Fatalf("Inject(%v) failed: %v", test.format, err)
+		}
+		child, err := tracer.Join("y", test.format, test.carrier)
+		if err != nil {
+			t.Fatalf("Join(%v) failed: %v", test.format, err)
+		}
+		raw := child.(*spanImpl).raw
+		if raw.Context.TraceID != spCtx.TraceID || raw.ParentSpanID != spCtx.SpanID {
+			t.Errorf("Join(%v): bad linkage %+v, expected parent %+v", test.format, raw, spCtx)
+		}
+		if !raw.LocalRoot || !raw.Context.Sampled {
+			t.Errorf("Join(%v): expected a sampled local root: %+v", test.format, raw)
+		}
+		if child.BaggageItem("checked") != "true" {
+			t.Errorf("Join(%v): baggage was not propagated", test.format)
+		}
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	tracer := New(recorder.NewInMemoryRecorder())
+
+	empty := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+	if _, err := tracer.Join("x", opentracing.TextMap, empty); err != opentracing.ErrTraceNotFound {
+		t.Errorf("Expected ErrTraceNotFound, got %v", err)
+	}
+	partial := opentracing.HTTPHeaderTextMapCarrier(http.Header{"Ot-Tracer-Traceid": {"1"}})
+	if _, err := tracer.Join("x", opentracing.TextMap, partial); err != opentracing.ErrTraceCorrupted {
+		t.Errorf("Expected ErrTraceCorrupted, got %v", err)
+	}
+	garbage := opentracing.HTTPHeaderTextMapCarrier(http.Header{
+		"Ot-Tracer-Traceid": {"zzz"},
+		"Ot-Tracer-Spanid":  {"1"},
+	})
+	if _, err := tracer.Join("x", opentracing.TextMap, garbage); err != opentracing.ErrTraceCorrupted {
+		t.Errorf("Expected ErrTraceCorrupted, got %v", err)
+	}
+	if _, err := tracer.Join("x", opentracing.Binary, &bytes.Buffer{}); err != opentracing.ErrTraceNotFound {
+		t.Errorf("Expected ErrTraceNotFound, got %v", err)
+	}
+	if _, err := tracer.Join("x", opentracing.Binary, bytes.NewBufferString("short")); err != opentracing.ErrTraceCorrupted {
+		t.Errorf("Expected ErrTraceCorrupted, got %v", err)
+	}
+	if _, err := tracer.Join("x", opentracing.Binary, empty); err != opentracing.ErrInvalidCarrier {
+		t.Errorf("Expected ErrInvalidCarrier, got %v", err)
+	}
+	if _, err := tracer.Join("x", "unknown", empty); err != opentracing.ErrUnsupportedFormat {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+	if err := tracer.Inject(opentracing.NoopTracer{}.StartSpan("x"), opentracing.TextMap, empty); err != opentracing.ErrInvalidSpan {
+		t.Errorf("Expected ErrInvalidSpan, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package basictracer
+
+import (
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+	"github.com/opentracing/opentracing-go/sampling"
+)
+
+// Span provides access to the essential details of the span, for use by
+// basictracer consumers. These methods may only be used prior to
+// Span.Finish().
+type Span interface {
+	opentracing.Span
+
+	// Context returns a copy of the Span's identifiers, sampling decision
+	// and baggage; the caller may modify it.
+	Context() recorder.SpanContext
+
+	// Operation returns the operation name of the Span.
+	Operation() string
+
+	// Start indicates when the span began.
+	Start() time.Time
+}
+
+// spanImpl implements the `Span` interface. Created via tracerImpl (see
+// `basictracer.New()`).
+type spanImpl struct {
+	tracer *tracerImpl
+
+	sync.Mutex // protects the fields below
+	raw        recorder.RawSpan
+	finished   bool
+}
+
+func (s *spanImpl) SetOperationName(operationName string) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	s.raw.Operation = operationName
+	return s
+}
+
+func (s *spanImpl) SetTag(key string, value interface{}) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	if key == string(ext.SamplingPriority) {
+		if sampled, ok := sampling.Priority(value); ok {
+			s.raw.Context.Sampled = sampled
+		}
+	}
+	s.raw.Tags[key] = value
+	return s
+}
+
+func (s *spanImpl) LogEvent(event string) {
+	s.Log(opentracing.LogData{
+		Event: event,
+	})
+}
+
+func (s *spanImpl) LogEventWithPayload(event string, payload interface{}) {
+	s.Log(opentracing.LogData{
+		Event:   event,
+		Payload: payload,
+	})
+}
+
+func (s *spanImpl) Log(ld opentracing.LogData) {
+	if ld.Timestamp.IsZero() {
+		ld.Timestamp = time.Now()
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.raw.Logs = append(s.raw.Logs, ld)
+}
+
+func (s *spanImpl) Finish() {
+	s.FinishWithOptions(opentracing.FinishOptions{})
+}
+
+func (s *spanImpl) FinishWithOptions(opts opentracing.FinishOptions) {
+	finishTime := opts.FinishTime
+	if finishTime.IsZero() {
+		finishTime = time.Now()
+	}
+	s.Lock()
+	if s.finished {
+		s.Unlock()
+		return
+	}
+	s.finished = true
+	s.raw.Duration = finishTime.Sub(s.raw.Start)
+	s.raw.Logs = append(s.raw.Logs, opts.BulkLogData...)
+	raw := s.snapshotLocked()
+	s.Unlock()
+
+	if raw.Context.Sampled && s.tracer.options.Recorder != nil {
+		s.tracer.options.Recorder.RecordSpan(raw)
+	}
+}
+
+// IsRecording belongs to the opentracing.RecordingSpan interface: a Span is
+// recording until it finishes, if it is sampled.
+func (s *spanImpl) IsRecording() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.raw.Context.Sampled && !s.finished
+}
+
+// snapshotLocked returns a deep copy of s.raw, so that the recorder owns its
+// RawSpan even if the application keeps using the Span.
+func (s *spanImpl) snapshotLocked() recorder.RawSpan {
+	raw := s.raw
+	raw.Context.Baggage = copyBaggage(s.raw.Context.Baggage)
+	raw.Tags = make(opentracing.Tags, len(s.raw.Tags))
+	for k, v := range s.raw.Tags {
+		raw.Tags[k] = v
+	}
+	raw.Logs = make([]opentracing.LogData, len(s.raw.Logs))
+	copy(raw.Logs, s.raw.Logs)
+	return raw
+}
+
+func (s *spanImpl) SetBaggageItem(restrictedKey, val string) opentracing.Span {
+	canonicalKey, valid := opentracing.CanonicalizeBaggageKey(restrictedKey)
+	if !valid {
+		return s
+	}
+	s.Lock()
+	defer s.Unlock()
+	if !s.tracer.options.BaggagePolicy.Admit(s.raw.Context.Baggage, canonicalKey, val) {
+		return s
+	}
+	// The baggage map may be shared with the parent Span (see
+	// StartSpanWithOptions), so it is copied on write.
+	baggage := copyBaggage(s.raw.Context.Baggage)
+	baggage[canonicalKey] = val
+	s.raw.Context.Baggage = baggage
+	return s
+}
+
+func (s *spanImpl) BaggageItem(restrictedKey string) string {
+	canonicalKey, valid := opentracing.CanonicalizeBaggageKey(restrictedKey)
+	if !valid {
+		return ""
+	}
+	s.Lock()
+	defer s.Unlock()
+	return s.raw.Context.Baggage[canonicalKey]
+}
+
+func (s *spanImpl) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *spanImpl) Context() recorder.SpanContext {
+	s.Lock()
+	defer s.Unlock()
+	ctx := s.raw.Context
+	if ctx.Baggage != nil {
+		ctx.Baggage = copyBaggage(ctx.Baggage)
+	}
+	return ctx
+}
+
+func (s *spanImpl) Operation() string {
+	s.Lock()
+	defer s.Unlock()
+	return s.raw.Operation
+}
+
+func (s *spanImpl) Start() time.Time {
+	return s.raw.Start
+}
+
+func copyBaggage(baggage map[string]string) map[string]string {
+	rval := make(map[string]string, len(baggage))
+	for k, v := range baggage {
+		rval[k] = v
+	}
+	return rval
+}
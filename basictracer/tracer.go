@@ -0,0 +1,193 @@
+// Package basictracer is a reference implementation of opentracing.Tracer.
+//
+// It assigns random 64- or 128-bit IDs, propagates Spans and their baggage
+// through all BuiltinFormats, and hands an immutable recorder.RawSpan to a
+// recorder.SpanRecorder whenever a sampled Span finishes. Exporters and span
+// processors are built on top of the SpanRecorder interface.
+//
+// The Parent in StartSpanOptions must be a Span created by a basictracer
+// Tracer. Any other Parent, including a Span returned by a wrapping Tracer
+// (see the decorator, multitracer, tracker and validate packages), is
+// ignored and the new Span starts a new trace. The wrapping Tracers unwrap
+// their own Spans before calling the Tracer they wrap.
+package basictracer
+
+import (
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+	"github.com/opentracing/opentracing-go/sampling"
+)
+
+// Options allows creating a customized Tracer via NewWithOptions. The object
+// must not be updated when there is an active Tracer using it.
+type Options struct {
+	// Recorder receives every sampled Span once it finishes. Required.
+	Recorder recorder.SpanRecorder
+
+	// Sampler makes the head sampling decision for every new Span. Spans
+	// that are not sampled are still propagated but never recorded.
+	//
+	// Defaults to sampling.ParentBased(sampling.Const(true)).
+	Sampler sampling.Sampler
+
+	// TraceID128Bit makes the Tracer generate 128-bit trace IDs for new
+	// traces. Joined traces keep the width of the incoming trace ID.
+	TraceID128Bit bool
+
+	// BaggagePolicy, if non-nil, is applied by Span.SetBaggageItem() and to
+	// the baggage decoded by Tracer.Join().
+	BaggagePolicy *opentracing.BaggagePolicy
+
+	// OnStart, if non-nil, is called with every new Span (including those
+	// created by Tracer.Join()) before it is returned to the caller.
+	OnStart func(sp Span)
+}
+
+// DefaultOptions returns an Options object with a sampler that records every
+// trace and no Recorder; callers must set the Recorder before using it.
+func DefaultOptions() Options {
+	return Options{
+		Sampler: sampling.ParentBased(sampling.Const(true)),
+	}
+}
+
+// New creates and returns a Tracer which records Spans to `recorder`, using
+// DefaultOptions otherwise.
+func New(recorder recorder.SpanRecorder) opentracing.Tracer {
+	opts := DefaultOptions()
+	opts.Recorder = recorder
+	return NewWithOptions(opts)
+}
+
+// NewWithOptions creates a customized Tracer.
+func NewWithOptions(opts Options) opentracing.Tracer {
+	if opts.Sampler == nil {
+		opts.Sampler = DefaultOptions().Sampler
+	}
+	return &tracerImpl{options: opts}
+}
+
+// tracerImpl implements the `opentracing.Tracer` interface.
+type tracerImpl struct {
+	options Options
+}
+
+func (t *tracerImpl) StartSpan(operationName string) opentracing.Span {
+	return t.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: operationName,
+	})
+}
+
+func (t *tracerImpl) StartSpanWithOptions(opts opentracing.StartSpanOptions) opentracing.Span {
+	startTime := opts.StartTime
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	tags := opts.Tags
+	if tags == nil {
+		tags = opentracing.Tags{}
+	}
+
+	sp := &spanImpl{tracer: t}
+	sp.raw.Operation = opts.OperationName
+	sp.raw.Start = startTime
+	sp.raw.Tags = tags
+	sp.raw.Context.SpanID = randomID()
+
+	var parentDecision *sampling.Decision
+	if parent, ok := opts.Parent.(*spanImpl); ok {
+		pctx := parent.Context()
+		sp.raw.Context.TraceID = pctx.TraceID
+		sp.raw.ParentSpanID = pctx.SpanID
+		sp.raw.Context.Baggage = pctx.Baggage
+		parentDecision = &sampling.Decision{Sampled: pctx.Sampled}
+	} else {
+		sp.raw.Context.TraceID = t.newTraceID()
+		sp.raw.LocalRoot = true
+	}
+	t.sample(sp, parentDecision)
+	return t.started(sp)
+}
+
+// startJoined starts a local root Span whose parent is described by the
+// remote `parent` context.
+func (t *tracerImpl) startJoined(operationName string, parent recorder.SpanContext) *spanImpl {
+	sp := &spanImpl{tracer: t}
+	sp.raw.Operation = operationName
+	sp.raw.Start = time.Now()
+	sp.raw.Tags = opentracing.Tags{}
+	sp.raw.LocalRoot = true
+	sp.raw.ParentSpanID = parent.SpanID
+	sp.raw.Context.TraceID = parent.TraceID
+	sp.raw.Context.SpanID = randomID()
+	sp.raw.Context.Baggage = t.options.BaggagePolicy.Filter(parent.Baggage)
+	t.sample(sp, &sampling.Decision{Sampled: parent.Sampled})
+	return t.started(sp)
+}
+
+func (t *tracerImpl) sample(sp *spanImpl, parent *sampling.Decision) {
+	decision := t.options.Sampler.ShouldSample(sampling.Params{
+		OperationName: sp.raw.Operation,
+		Tags:          sp.raw.Tags,
+		TraceID:       sp.raw.Context.TraceID.Low,
+		Parent:        parent,
+	})
+	sp.raw.Context.Sampled = decision.Sampled
+	if decision.Sampled {
+		for k, v := range decision.Tags {
+			if _, ok := sp.raw.Tags[k]; !ok {
+				sp.raw.Tags[k] = v
+			}
+		}
+	}
+}
+
+func (t *tracerImpl) started(sp *spanImpl) *spanImpl {
+	if t.options.OnStart != nil {
+		t.options.OnStart(sp)
+	}
+	return sp
+}
+
+func (t *tracerImpl) newTraceID() recorder.TraceID {
+	id := recorder.TraceID{Low: randomID()}
+	if t.options.TraceID128Bit {
+		id.High = randomID()
+	}
+	return id
+}
+
+func (t *tracerImpl) Inject(sp opentracing.Span, format interface{}, carrier interface{}) error {
+	span, ok := sp.(*spanImpl)
+	if !ok {
+		return opentracing.ErrInvalidSpan
+	}
+	switch format {
+	case opentracing.TextMap:
+		return injectTextMap(span.Context(), carrier)
+	case opentracing.Binary:
+		return injectBinary(span.Context(), carrier)
+	}
+	return opentracing.ErrUnsupportedFormat
+}
+
+func (t *tracerImpl) Join(operationName string, format interface{}, carrier interface{}) (opentracing.Span, error) {
+	var (
+		ctx recorder.SpanContext
+		err error
+	)
+	switch format {
+	case opentracing.TextMap:
+		ctx, err = joinTextMap(carrier)
+	case opentracing.Binary:
+		ctx, err = joinBinary(carrier)
+	default:
+		err = opentracing.ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t.startJoined(operationName, ctx), nil
+}
@@ -0,0 +1,148 @@
+package basictracer
+
+import (
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+	"github.com/opentracing/opentracing-go/sampling"
+)
+
+func TestSpanParentLinkage(t *testing.T) {
+	rec := recorder.NewInMemoryRecorder()
+	tracer := New(rec)
+
+	parent := tracer.StartSpan("parent")
+	parent.SetBaggageItem("User", "alice")
+	child := opentracing.StartChildSpan(parent, "child")
+	child.SetTag("k", "v")
+	child.LogEvent("hello")
+	child.Finish()
+	parent.Finish()
+
+	spans := rec.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("Recorded %v spans, expected 2", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.Context.TraceID != p.Context.TraceID {
+		t.Errorf("Trace IDs differ: %v != %v", c.Context.TraceID, p.Context.TraceID)
+	}
+	if c.ParentSpanID != p.Context.SpanID || p.ParentSpanID != 0 {
+		t.Errorf("Bad parent linkage: child=%+v parent=%+v", c, p)
+	}
+	if !p.LocalRoot || c.LocalRoot {
+		t.Errorf("Bad LocalRoot: child=%v parent=%v", c.LocalRoot, p.LocalRoot)
+	}
+	if c.Context.Baggage["user"] != "alice" {
+		t.Errorf("Child did not inherit baggage: %v", c.Context.Baggage)
+	}
+	if c.Tags["k"] != "v" || len(c.Logs) != 1 || c.Logs[0].Timestamp.IsZero() {
+		t.Errorf("Unexpected child tags or logs: %+v", c)
+	}
+	if c.Context.TraceID.High != 0 {
+		t.Errorf("Expected a 64-bit trace ID, got %v", c.Context.TraceID)
+	}
+}
+
+func TestSpanSnapshotIsImmutable(t *testing.T) {
+	rec := recorder.NewInMemoryRecorder()
+	tracer := New(rec)
+	span := tracer.StartSpan("x")
+	span.SetTag("k", "before")
+	span.Finish()
+	span.SetTag("k", "after")
+	span.Finish()
+
+	spans := rec.GetSpans()
+	if len(spans) != 1 || spans[0].Tags["k"] != "before" {
+		t.Errorf("Unexpected recorded spans: %+v", spans)
+	}
+
+	span.SetBaggageItem("user", "alice")
+	span.(Span).Context().Baggage["user"] = "bob"
+	if v := span.BaggageItem("user"); v != "alice" {
+		t.Errorf("Context() shares its baggage with the Span: %v", v)
+	}
+}
+
+func TestFinishWithOptions(t *testing.T) {
+	rec := recorder.NewInMemoryRecorder()
+	tracer := New(rec)
+	start := time.Unix(1000, 0)
+	span := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: "x",
+		StartTime:     start,
+	})
+	span.FinishWithOptions(opentracing.FinishOptions{
+		FinishTime:  start.Add(time.Second),
+		BulkLogData: []opentracing.LogData{{Timestamp: start, Event: "bulk"}},
+	})
+	raw := rec.GetSpans()[0]
+	if raw.Duration != time.Second || raw.Finish() != start.Add(time.Second) {
+		t.Errorf("Unexpected duration %v", raw.Duration)
+	}
+	if len(raw.Logs) != 1 || raw.Logs[0].Event != "bulk" {
+		t.Errorf("Unexpected logs %+v", raw.Logs)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	rec := recorder.NewInMemoryRecorder()
+	opts := DefaultOptions()
+	opts.Recorder = rec
+	opts.Sampler = sampling.WithPriority(sampling.ParentBased(sampling.Const(false)))
+	opts.TraceID128Bit = true
+	tracer := NewWithOptions(opts)
+
+	dropped := tracer.StartSpan("dropped")
+	if opentracing.IsRecording(dropped) {
+		t.Error("Expected an unsampled span not to be recording")
+	}
+	dropped.Finish()
+	forced := tracer.StartSpan("forced")
+	ext.SamplingPriority.Set(forced, 1)
+	if !opentracing.IsRecording(forced) {
+		t.Error("Expected a forced span to be recording")
+	}
+	opentracing.StartChildSpan(forced, "child").Finish()
+	forced.Finish()
+	if opentracing.IsRecording(forced) {
+		t.Error("Expected a finished span not to be recording")
+	}
+
+	spans := rec.GetSpans()
+	if len(spans) != 2 || spans[0].Operation != "child" || spans[1].Operation != "forced" {
+		t.Fatalf("Unexpected recorded spans: %+v", spans)
+	}
+	if spans[1].Context.TraceID.High == 0 {
+		t.Errorf("Expected a 128-bit trace ID, got %v", spans[1].Context.TraceID)
+	}
+}
+
+func TestBaggagePolicy(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Recorder = recorder.NewInMemoryRecorder()
+	opts.BaggagePolicy = &opentracing.BaggagePolicy{MaxItems: 1}
+	tracer := NewWithOptions(opts)
+
+	span := tracer.StartSpan("x")
+	span.SetBaggageItem("a", "1").SetBaggageItem("b", "2")
+	if span.BaggageItem("a") != "1" || span.BaggageItem("b") != "" {
+		t.Errorf("Unexpected baggage %v", span.(Span).Context().Baggage)
+	}
+}
+
+func TestOnStart(t *testing.T) {
+	var started []string
+	opts := DefaultOptions()
+	opts.OnStart = func(sp Span) { started = append(started, sp.Operation()) }
+	tracer := NewWithOptions(opts)
+	tracer.StartSpan("a").Finish()
+	tracer.StartSpan("b").Finish()
+	if len(started) != 2 || started[0] != "a" || started[1] != "b" {
+		t.Errorf("Unexpected OnStart calls: %v", started)
+	}
+}
@@ -0,0 +1,213 @@
+// Package decorator wraps an opentracing.Tracer with Hooks, so that small
+// cross-cutting policies (adding a tag to every span, vetoing operation
+// names, rewriting log data, ...) can be composed without re-implementing
+// the Tracer and Span interfaces each time.
+//
+// Example: tag every span with the deployment zone and drop health checks.
+//
+//	tracer := decorator.New(inner, decorator.Hooks{
+//	    OnStartSpan: func(opts *opentracing.StartSpanOptions) bool {
+//	        if opts.OperationName == "health" {
+//	            return false
+//	        }
+//	        opts.Tags = opentracing.Tags{"zone": zone}.Merge(opts.Tags)
+//	        return true
+//	    },
+//	})
+package decorator
+
+import (
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Hooks intercept calls to a decorated Tracer and its Spans. Every field is
+// optional. The `sp` passed to span hooks is the decorated Span, so it can
+// be used as a map key to keep per-span state.
+type Hooks struct {
+	// OnStartSpan may modify `opts` before the Span is started. Returning
+	// false vetoes the Span: a no-op Span is returned instead, and so are
+	// all of its children. It is not called for Spans created by Join().
+	OnStartSpan func(opts *opentracing.StartSpanOptions) bool
+
+	// OnStarted is called with every new Span, including those created by
+	// Join(), before it is returned to the caller.
+	OnStarted func(sp opentracing.Span)
+
+	// OnSetTag may rewrite a tag; returning false for `keep` drops it.
+	OnSetTag func(sp opentracing.Span, key string, value interface{}) (newKey string, newValue interface{}, keep bool)
+
+	// OnLog may modify `data`; returning false drops it. It is called for
+	// Log(), LogEvent() and LogEventWithPayload().
+	OnLog func(sp opentracing.Span, data *opentracing.LogData) bool
+
+	// OnFinish may modify `opts` before the Span finishes. It is called for
+	// both Finish() and FinishWithOptions().
+	OnFinish func(sp opentracing.Span, opts *opentracing.FinishOptions)
+
+	// OnInject is called before Inject(); a non-nil error is returned to the
+	// caller instead of injecting.
+	OnInject func(sp opentracing.Span, format interface{}, carrier interface{}) error
+
+	// OnJoin is called before Join(); a non-nil error is returned to the
+	// caller instead of joining.
+	OnJoin func(operationName string, format interface{}, carrier interface{}) error
+}
+
+// New returns a Tracer that runs `hooks` (in order) around the calls it
+// forwards to `tracer`.
+func New(tracer opentracing.Tracer, hooks ...Hooks) opentracing.Tracer {
+	return &decoratedTracer{tracer: tracer, hooks: hooks}
+}
+
+// Unwrap returns the Span wrapped by a decorated Span, or `sp` itself if it
+// was not created by a decorated Tracer.
+func Unwrap(sp opentracing.Span) opentracing.Span {
+	if ds, ok := sp.(*decoratedSpan); ok {
+		return ds.span
+	}
+	return sp
+}
+
+type decoratedTracer struct {
+	tracer opentracing.Tracer
+	hooks  []Hooks
+}
+
+func (t *decoratedTracer) StartSpan(operationName string) opentracing.Span {
+	return t.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: operationName,
+	})
+}
+
+func (t *decoratedTracer) StartSpanWithOptions(opts opentracing.StartSpanOptions) opentracing.Span {
+	if opts.Parent != nil {
+		if _, vetoed := opts.Parent.Tracer().(opentracing.NoopTracer); vetoed {
+			return opts.Parent
+		}
+	}
+	for _, h := range t.hooks {
+		if h.OnStartSpan != nil && !h.OnStartSpan(&opts) {
+			return opentracing.NoopTracer{}.StartSpan(opts.OperationName)
+		}
+	}
+	opts.Parent = Unwrap(opts.Parent)
+	return t.started(t.tracer.StartSpanWithOptions(opts))
+}
+
+func (t *decoratedTracer) started(span opentracing.Span) opentracing.Span {
+	sp := &decoratedSpan{tracer: t, span: span}
+	for _, h := range t.hooks {
+		if h.OnStarted != nil {
+			h.OnStarted(sp)
+		}
+	}
+	return sp
+}
+
+func (t *decoratedTracer) Inject(sp opentracing.Span, format interface{}, carrier interface{}) error {
+	for _, h := range t.hooks {
+		if h.OnInject != nil {
+			if err := h.OnInject(sp, format, carrier); err != nil {
+				return err
+			}
+		}
+	}
+	return t.tracer.Inject(Unwrap(sp), format, carrier)
+}
+
+func (t *decoratedTracer) Join(operationName string, format interface{}, carrier interface{}) (opentracing.Span, error) {
+	for _, h := range t.hooks {
+		if h.OnJoin != nil {
+			if err := h.OnJoin(operationName, format, carrier); err != nil {
+				return nil, err
+			}
+		}
+	}
+	span, err := t.tracer.Join(operationName, format, carrier)
+	if err != nil {
+		return nil, err
+	}
+	return t.started(span), nil
+}
+
+// decoratedSpan runs the span hooks of its tracer.
+type decoratedSpan struct {
+	tracer *decoratedTracer
+	span   opentracing.Span
+}
+
+func (s *decoratedSpan) SetOperationName(operationName string) opentracing.Span {
+	s.span.SetOperationName(operationName)
+	return s
+}
+
+func (s *decoratedSpan) SetTag(key string, value interface{}) opentracing.Span {
+	for _, h := range s.tracer.hooks {
+		if h.OnSetTag == nil {
+			continue
+		}
+		var keep bool
+		if key, value, keep = h.OnSetTag(s, key, value); !keep {
+			return s
+		}
+	}
+	s.span.SetTag(key, value)
+	return s
+}
+
+func (s *decoratedSpan) Finish() {
+	var opts opentracing.FinishOptions
+	s.runFinishHooks(&opts)
+	if opts.FinishTime.IsZero() && opts.BulkLogData == nil {
+		s.span.Finish()
+		return
+	}
+	s.span.FinishWithOptions(opts)
+}
+
+func (s *decoratedSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	s.runFinishHooks(&opts)
+	s.span.FinishWithOptions(opts)
+}
+
+func (s *decoratedSpan) runFinishHooks(opts *opentracing.FinishOptions) {
+	for _, h := range s.tracer.hooks {
+		if h.OnFinish != nil {
+			h.OnFinish(s, opts)
+		}
+	}
+}
+
+func (s *decoratedSpan) LogEvent(event string) {
+	s.Log(opentracing.LogData{Event: event})
+}
+
+func (s *decoratedSpan) LogEventWithPayload(event string, payload interface{}) {
+	s.Log(opentracing.LogData{Event: event, Payload: payload})
+}
+
+func (s *decoratedSpan) Log(data opentracing.LogData) {
+	for _, h := range s.tracer.hooks {
+		if h.OnLog != nil && !h.OnLog(s, &data) {
+			return
+		}
+	}
+	s.span.Log(data)
+}
+
+func (s *decoratedSpan) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.span.SetBaggageItem(restrictedKey, value)
+	return s
+}
+
+func (s *decoratedSpan) BaggageItem(restrictedKey string) string {
+	return s.span.BaggageItem(restrictedKey)
+}
+
+func (s *decoratedSpan) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *decoratedSpan) IsRecording() bool {
+	return opentracing.IsRecording(s.span)
+}
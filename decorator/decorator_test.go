@@ -0,0 +1,114 @@
+package decorator_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/decorator"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestHooks(t *testing.T) {
+	mock := mocktracer.New()
+	var started, finished int
+	tracer := decorator.New(mock,
+		decorator.Hooks{
+			OnStartSpan: func(opts *opentracing.StartSpanOptions) bool {
+				if opts.OperationName == "health" {
+					return false
+				}
+				opts.Tags = opentracing.Tags{"zone": "us-east"}.Merge(opts.Tags)
+				return true
+			},
+			OnStarted: func(sp opentracing.Span) { started++ },
+			OnFinish:  func(sp opentracing.Span, opts *opentracing.FinishOptions) { finished++ },
+		},
+		decorator.Hooks{
+			OnSetTag: func(sp opentracing.Span, key string, value interface{}) (string, interface{}, bool) {
+				if key == "password" {
+					return "", nil, false
+				}
+				return strings.ToLower(key), value, true
+			},
+			OnLog: func(sp opentracing.Span, data *opentracing.LogData) bool {
+				data.Event = "[" + data.Event + "]"
+				return data.Event != "[drop]"
+			},
+		},
+	)
+
+	health := tracer.StartSpan("health")
+	opentracing.StartChildSpan(health, "child").Finish()
+	health.Finish()
+
+	sp := tracer.StartSpan("GetFeed")
+	sp.SetTag("password", "hunter2").SetTag("User", "alice")
+	sp.LogEvent("hello")
+	sp.LogEvent("drop")
+	child := opentracing.StartChildSpan(sp, "child")
+	if child.Tracer() != tracer {
+		t.Error("Expected children to be started by the decorated tracer")
+	}
+	child.Finish()
+	sp.Finish()
+
+	if started != 2 || finished != 2 {
+		t.Errorf("started=%v finished=%v, expected 2 and 2", started, finished)
+	}
+	spans := mock.FinishedSpans
+	if len(spans) != 2 {
+		t.Fatalf("Unexpected finished spans %v", spans)
+	}
+	root := spans[1]
+	if spans[0].ParentID != root.SpanID {
+		t.Errorf("Bad parent linkage")
+	}
+	if len(root.Tags) != 2 || root.Tags["user"] != "alice" || root.Tags["zone"] != "us-east" {
+		t.Errorf("Unexpected tags %v", root.Tags)
+	}
+	if len(root.Logs) != 1 || root.Logs[0].Event != "[hello]" {
+		t.Errorf("Unexpected logs %v", root.Logs)
+	}
+	if root.FinishTime.IsZero() {
+		t.Error("Expected Finish() to be forwarded")
+	}
+}
+
+func TestInjectJoinHooks(t *testing.T) {
+	errBlocked := errors.New("blocked")
+	var injected, joined int
+	tracer := decorator.New(mocktracer.New(), decorator.Hooks{
+		OnInject: func(sp opentracing.Span, format interface{}, carrier interface{}) error {
+			injected++
+			if format == opentracing.Binary {
+				return errBlocked
+			}
+			return nil
+		},
+		OnJoin: func(operationName string, format interface{}, carrier interface{}) error {
+			joined++
+			return nil
+		},
+	})
+	sp := tracer.StartSpan("client")
+	carrier := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+	if err := tracer.Inject(sp, opentracing.TextMap, carrier); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracer.Inject(sp, opentracing.Binary, carrier); err != errBlocked {
+		t.Errorf("Expected errBlocked, got %v", err)
+	}
+	server, err := tracer.Join("server", opentracing.TextMap, carrier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decorator.Unwrap(server).(*mocktracer.MockSpan).ParentID != decorator.Unwrap(sp).(*mocktracer.MockSpan).SpanID {
+		t.Error("Joined span is not a child of the injected span")
+	}
+	if injected != 2 || joined != 1 {
+		t.Errorf("injected=%v joined=%v", injected, joined)
+	}
+}
@@ -0,0 +1,135 @@
+package jaeger
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Thrift compact protocol constants. See
+// https://github.com/apache/thrift/blob/master/doc/specs/thrift-compact-protocol.md
+const (
+	compactProtocolID  = 0x82
+	compactVersion     = 1
+	compactTypeShift   = 5
+	messageTypeOneway  = 4
+	compactStop        = 0x00
+	compactBoolTrue    = 0x01
+	compactBoolFalse   = 0x02
+	compactI32         = 0x05
+	compactI64         = 0x06
+	compactDouble      = 0x07
+	compactBinary      = 0x08
+	compactList        = 0x09
+	compactStruct      = 0x0C
+	compactMaxFieldGap = 15
+)
+
+// compactWriter encodes the subset of the thrift compact protocol needed to
+// emit Jaeger batches. Fields must be written in increasing id order within
+// each struct.
+type compactWriter struct {
+	buf       []byte
+	lastField []int16 // stack of the last field id written per open struct
+}
+
+func (w *compactWriter) bytes() []byte {
+	return w.buf
+}
+
+func (w *compactWriter) writeMessageBegin(name string, messageType byte, seqID int32) {
+	w.buf = append(w.buf, compactProtocolID, compactVersion|messageType<<compactTypeShift)
+	w.writeVarint(uint64(uint32(seqID)))
+	w.writeStringValue(name)
+}
+
+func (w *compactWriter) structBegin() {
+	w.lastField = append(w.lastField, 0)
+}
+
+func (w *compactWriter) structEnd() {
+	w.buf = append(w.buf, compactStop)
+	w.lastField = w.lastField[:len(w.lastField)-1]
+}
+
+func (w *compactWriter) fieldHeader(id int16, fieldType byte) {
+	last := &w.lastField[len(w.lastField)-1]
+	if delta := id - *last; delta > 0 && delta <= compactMaxFieldGap {
+		w.buf = append(w.buf, byte(delta)<<4|fieldType)
+	} else {
+		w.buf = append(w.buf, fieldType)
+		w.writeVarint(zigzag(int64(id)))
+	}
+	*last = id
+}
+
+func (w *compactWriter) writeStructField(id int16) {
+	w.fieldHeader(id, compactStruct)
+	w.structBegin()
+}
+
+func (w *compactWriter) writeListField(id int16, elemType byte, size int) {
+	w.fieldHeader(id, compactList)
+	w.writeListHeader(elemType, size)
+}
+
+func (w *compactWriter) writeListHeader(elemType byte, size int) {
+	if size < 15 {
+		w.buf = append(w.buf, byte(size)<<4|elemType)
+		return
+	}
+	w.buf = append(w.buf, 0xF0|elemType)
+	w.writeVarint(uint64(size))
+}
+
+func (w *compactWriter) writeBool(id int16, v bool) {
+	if v {
+		w.fieldHeader(id, compactBoolTrue)
+	} else {
+		w.fieldHeader(id, compactBoolFalse)
+	}
+}
+
+func (w *compactWriter) writeI32(id int16, v int32) {
+	w.fieldHeader(id, compactI32)
+	w.writeVarint(zigzag(int64(v)))
+}
+
+func (w *compactWriter) writeI64(id int16, v int64) {
+	w.fieldHeader(id, compactI64)
+	w.writeVarint(zigzag(v))
+}
+
+func (w *compactWriter) writeDouble(id int16, v float64) {
+	w.fieldHeader(id, compactDouble)
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	w.buf = append(w.buf, b[:]...)
+}
+
+func (w *compactWriter) writeString(id int16, v string) {
+	w.fieldHeader(id, compactBinary)
+	w.writeStringValue(v)
+}
+
+func (w *compactWriter) writeBinary(id int16, v []byte) {
+	w.fieldHeader(id, compactBinary)
+	w.writeVarint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *compactWriter) writeStringValue(v string) {
+	w.writeVarint(uint64(len(v)))
+	w.buf = append(w.buf, v...)
+}
+
+func (w *compactWriter) writeVarint(v uint64) {
+	for v >= 0x80 {
+		w.buf = append(w.buf, byte(v)|0x80)
+		v >>= 7
+	}
+	w.buf = append(w.buf, byte(v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
@@ -0,0 +1,182 @@
+// Package jaeger exports finished spans to a Jaeger agent over UDP, using
+// the agent's thrift compact protocol endpoint (port 6831 by default).
+//
+// The Exporter splits every ExportSpans call into as many UDP packets as
+// needed to respect the agent's maximum packet size; pair it with a
+// processor.Batcher to batch spans from a basictracer.
+package jaeger
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// DefaultAgentAddr is the host:port of a Jaeger agent running on the local
+// host.
+const DefaultAgentAddr = "127.0.0.1:6831"
+
+// DefaultMaxPacketSize is the largest UDP packet accepted by the Jaeger
+// agent by default.
+const DefaultMaxPacketSize = 65000
+
+// ErrSpanTooLarge is reported for spans whose encoding alone exceeds
+// Options.MaxPacketSize.
+var ErrSpanTooLarge = errors.New("jaeger: span too large for a single UDP packet")
+
+// Options configures an Exporter.
+type Options struct {
+	// ServiceName identifies this process to Jaeger. Required.
+	ServiceName string
+
+	// ProcessTags are attached to the Jaeger Process of every batch (e.g.,
+	// "hostname" or "ip").
+	ProcessTags opentracing.Tags
+
+	// AgentAddr is the agent's UDP host:port. Defaults to DefaultAgentAddr.
+	AgentAddr string
+
+	// MaxPacketSize bounds the size of each UDP packet. Defaults to
+	// DefaultMaxPacketSize.
+	MaxPacketSize int
+
+	// OnDrop, if non-nil, is called whenever spans are dropped, either
+	// because they do not fit in a packet or because sending failed.
+	OnDrop func(spans int, err error)
+}
+
+// Stats holds counters describing the activity of an Exporter.
+type Stats struct {
+	PacketsSent    int64
+	SpansSent      int64
+	PacketsDropped int64
+	SpansDropped   int64
+}
+
+// Exporter emits spans to a Jaeger agent. It implements processor.Exporter.
+type Exporter struct {
+	options Options
+	conn    net.Conn
+	seqID   int32
+
+	lock  sync.Mutex
+	stats Stats
+}
+
+// NewExporter returns an Exporter that sends to the agent described by
+// `opts`.
+func NewExporter(opts Options) (*Exporter, error) {
+	if opts.AgentAddr == "" {
+		opts.AgentAddr = DefaultAgentAddr
+	}
+	if opts.MaxPacketSize <= 0 {
+		opts.MaxPacketSize = DefaultMaxPacketSize
+	}
+	conn, err := net.Dial("udp", opts.AgentAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &Exporter{options: opts, conn: conn}, nil
+}
+
+// Close closes the UDP socket.
+func (e *Exporter) Close() error {
+	return e.conn.Close()
+}
+
+// Stats returns a snapshot of the Exporter's counters.
+func (e *Exporter) Stats() Stats {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.stats
+}
+
+// packetOverhead is an upper bound on the bytes a packet needs beyond the
+// encoded spans and the message prefix for sequence number zero: the extra
+// varint bytes of larger sequence numbers, the spans field header, the list
+// header and the two struct stops.
+const packetOverhead = 4 + 1 + 6 + 2
+
+// ExportSpans sends `spans` to the agent, splitting them across packets as
+// needed. It returns the last error encountered; dropped spans are also
+// reported via Options.OnDrop and Stats.
+func (e *Exporter) ExportSpans(spans []recorder.RawSpan) error {
+	var lastErr error
+	budget := e.options.MaxPacketSize - len(e.packetPrefix(0).bytes()) - packetOverhead
+	var (
+		batch     [][]byte
+		batchSize int
+	)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			lastErr = err
+		}
+		batch, batchSize = nil, 0
+	}
+	for _, raw := range spans {
+		encoded := encodeSpan(raw)
+		if len(encoded) > budget {
+			lastErr = ErrSpanTooLarge
+			e.dropped(1, ErrSpanTooLarge)
+			continue
+		}
+		if batchSize+len(encoded) > budget {
+			flush()
+		}
+		batch = append(batch, encoded)
+		batchSize += len(encoded)
+	}
+	flush()
+	return lastErr
+}
+
+// packetPrefix encodes everything that precedes the spans list in an
+// Agent.emitBatch() call: the message header, the start of the arguments
+// and Batch structs, and the Process.
+func (e *Exporter) packetPrefix(seqID int32) *compactWriter {
+	w := &compactWriter{}
+	w.writeMessageBegin("emitBatch", messageTypeOneway, seqID)
+	w.structBegin()       // emitBatch_args
+	w.writeStructField(1) // batch
+	writeProcess(w, 1, e.options.ServiceName, e.options.ProcessTags)
+	return w
+}
+
+func (e *Exporter) send(spans [][]byte) error {
+	w := e.packetPrefix(atomic.AddInt32(&e.seqID, 1))
+	w.writeListField(2, compactStruct, len(spans))
+	for _, s := range spans {
+		w.buf = append(w.buf, s...)
+	}
+	w.structEnd() // batch
+	w.structEnd() // emitBatch_args
+
+	if _, err := e.conn.Write(w.bytes()); err != nil {
+		e.lock.Lock()
+		e.stats.PacketsDropped++
+		e.lock.Unlock()
+		e.dropped(len(spans), err)
+		return err
+	}
+	e.lock.Lock()
+	e.stats.PacketsSent++
+	e.stats.SpansSent += int64(len(spans))
+	e.lock.Unlock()
+	return nil
+}
+
+func (e *Exporter) dropped(spans int, err error) {
+	e.lock.Lock()
+	e.stats.SpansDropped += int64(spans)
+	e.lock.Unlock()
+	if e.options.OnDrop != nil {
+		e.options.OnDrop(spans, err)
+	}
+}
@@ -0,0 +1,246 @@
+package jaeger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// compactReader decodes thrift compact data into generic values: structs
+// become map[int16]interface{}, lists []interface{}, binaries strings.
+type compactReader struct {
+	buf []byte
+}
+
+func (r *compactReader) byte() byte {
+	b := r.buf[0]
+	r.buf = r.buf[1:]
+	return b
+}
+
+func (r *compactReader) varint() uint64 {
+	v, n := binary.Uvarint(r.buf)
+	r.buf = r.buf[n:]
+	return v
+}
+
+func (r *compactReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *compactReader) str() string {
+	n := r.varint()
+	s := string(r.buf[:n])
+	r.buf = r.buf[n:]
+	return s
+}
+
+func (r *compactReader) value(t byte) interface{} {
+	switch t {
+	case compactBoolTrue:
+		return true
+	case compactBoolFalse:
+		return false
+	case compactI32, compactI64:
+		return r.zigzag()
+	case compactDouble:
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.buf))
+		r.buf = r.buf[8:]
+		return v
+	case compactBinary:
+		return r.str()
+	case compactList:
+		header := r.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := []interface{}{}
+		for i := 0; i < size; i++ {
+			list = append(list, r.value(header&0x0F))
+		}
+		return list
+	case compactStruct:
+		fields := map[int16]interface{}{}
+		last := int16(0)
+		for {
+			header := r.byte()
+			if header == compactStop {
+				return fields
+			}
+			id := last + int16(header>>4)
+			if header>>4 == 0 {
+				id = int16(r.zigzag())
+			}
+			fields[id] = r.value(header & 0x0F)
+			last = id
+		}
+	}
+	panic(fmt.Sprintf("unexpected type %v", t))
+}
+
+// decodeEmitBatch returns the service name and spans of an emitBatch packet.
+func decodeEmitBatch(t *testing.T, packet []byte) (string, []map[int16]interface{}) {
+	r := &compactReader{buf: packet}
+	if r.byte() != compactProtocolID || r.byte() != 0x81 {
+		t.Fatalf("Bad message header % x", packet[:2])
+	}
+	r.varint()
+	if name := r.str(); name != "emitBatch" {
+		t.Fatalf("Unexpected method %q", name)
+	}
+	args := r.value(compactStruct).(map[int16]interface{})
+	if len(r.buf) != 0 {
+		t.Fatalf("%v trailing bytes", len(r.buf))
+	}
+	batch := args[1].(map[int16]interface{})
+	process := batch[1].(map[int16]interface{})
+	var spans []map[int16]interface{}
+	for _, s := range batch[2].([]interface{}) {
+		spans = append(spans, s.(map[int16]interface{}))
+	}
+	return process[1].(string), spans
+}
+
+func tagMap(tags interface{}) map[string]interface{} {
+	rval := map[string]interface{}{}
+	for _, tag := range tags.([]interface{}) {
+		fields := tag.(map[int16]interface{})
+		for id := int16(3); id <= 7; id++ {
+			if v, ok := fields[id]; ok {
+				rval[fields[1].(string)] = v
+			}
+		}
+	}
+	return rval
+}
+
+func listen(t *testing.T) (*net.UDPConn, string) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, conn.LocalAddr().String()
+}
+
+func receive(t *testing.T, conn *net.UDPConn) []byte {
+	buf := make([]byte, DefaultMaxPacketSize)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf[:n]
+}
+
+func TestExportSpan(t *testing.T) {
+	agent, addr := listen(t)
+	defer agent.Close()
+	exporter, err := NewExporter(Options{ServiceName: "frontend", AgentAddr: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+
+	start := time.Unix(1000, 0)
+	err = exporter.ExportSpans([]recorder.RawSpan{{
+		Context: recorder.SpanContext{
+			TraceID: recorder.TraceID{High: 1, Low: 2},
+			SpanID:  3,
+			Sampled: true,
+			Baggage: map[string]string{"user": "alice"},
+		},
+		ParentSpanID: 4,
+		Operation:    "GetFeed",
+		Start:        start,
+		Duration:     time.Millisecond,
+		Tags:         opentracing.Tags{"component": "http", "error": true, "status": uint16(500), "ratio": 0.5},
+		Logs:         []opentracing.LogData{{Timestamp: start, Event: "retry", Payload: 2}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service, spans := decodeEmitBatch(t, receive(t, agent))
+	if service != "frontend" || len(spans) != 1 {
+		t.Fatalf("Unexpected batch: %v %v", service, spans)
+	}
+	span := spans[0]
+	expected := map[int16]interface{}{1: int64(2), 2: int64(1), 3: int64(3), 4: int64(4), 5: "GetFeed", 7: int64(1), 8: int64(1000000000), 9: int64(1000)}
+	for id, v := range expected {
+		if span[id] != v {
+			t.Errorf("Field %v = %v, expected %v", id, span[id], v)
+		}
+	}
+	tags := tagMap(span[10])
+	if tags["component"] != "http" || tags["error"] != true || tags["status"] != int64(500) || tags["ratio"] != 0.5 {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+	logs := span[11].([]interface{})
+	if len(logs) != 2 {
+		t.Fatalf("Unexpected logs %v", logs)
+	}
+	baggage := tagMap(logs[0].(map[int16]interface{})[2])
+	if baggage["event"] != BaggageLogEvent || baggage["key"] != "user" || baggage["value"] != "alice" {
+		t.Errorf("Unexpected baggage log %v", baggage)
+	}
+	event := tagMap(logs[1].(map[int16]interface{})[2])
+	if event["event"] != "retry" || event["payload"] != "2" {
+		t.Errorf("Unexpected event log %v", event)
+	}
+}
+
+func TestExportSplitsPackets(t *testing.T) {
+	agent, addr := listen(t)
+	defer agent.Close()
+	var dropped []int
+	exporter, err := NewExporter(Options{
+		ServiceName:   "frontend",
+		AgentAddr:     addr,
+		MaxPacketSize: 300,
+		OnDrop:        func(spans int, err error) { dropped = append(dropped, spans) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer exporter.Close()
+
+	var spans []recorder.RawSpan
+	for i := 0; i < 20; i++ {
+		spans = append(spans, recorder.RawSpan{Operation: fmt.Sprintf("op-%02d", i)})
+	}
+	spans = append(spans, recorder.RawSpan{Operation: strings.Repeat("x", 400)})
+	if err := exporter.ExportSpans(spans); err != ErrSpanTooLarge {
+		t.Errorf("Expected ErrSpanTooLarge, got %v", err)
+	}
+
+	stats := exporter.Stats()
+	if stats.PacketsSent < 2 || stats.SpansSent != 20 || stats.SpansDropped != 1 || len(dropped) != 1 {
+		t.Fatalf("Unexpected stats %+v, dropped %v", stats, dropped)
+	}
+	received := 0
+	for i := int64(0); i < stats.PacketsSent; i++ {
+		packet := receive(t, agent)
+		if len(packet) > 300 {
+			t.Errorf("Packet of %v bytes exceeds MaxPacketSize", len(packet))
+		}
+		_, spans := decodeEmitBatch(t, packet)
+		for _, s := range spans {
+			if s[5] != fmt.Sprintf("op-%02d", received) {
+				t.Errorf("Unexpected span %v", s[5])
+			}
+			received++
+		}
+	}
+	if received != 20 {
+		t.Errorf("Received %v spans, expected 20", received)
+	}
+}
@@ -0,0 +1,147 @@
+package jaeger
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Jaeger TagType enum values.
+const (
+	tagTypeString = 0
+	tagTypeDouble = 1
+	tagTypeBool   = 2
+	tagTypeLong   = 3
+	tagTypeBinary = 4
+)
+
+// Jaeger span flags.
+const (
+	flagSampled = 1
+)
+
+// BaggageLogEvent is the "event" log field of the log records that carry
+// baggage items, following the convention of the Jaeger clients.
+const BaggageLogEvent = "baggage"
+
+// encodeSpan returns the thrift compact encoding of `raw` as a jaeger.Span
+// struct suitable for use as a list element.
+func encodeSpan(raw recorder.RawSpan) []byte {
+	w := &compactWriter{}
+	w.structBegin()
+	w.writeI64(1, int64(raw.Context.TraceID.Low))
+	w.writeI64(2, int64(raw.Context.TraceID.High))
+	w.writeI64(3, int64(raw.Context.SpanID))
+	w.writeI64(4, int64(raw.ParentSpanID))
+	w.writeString(5, raw.Operation)
+	flags := int32(0)
+	if raw.Context.Sampled {
+		flags |= flagSampled
+	}
+	w.writeI32(7, flags)
+	w.writeI64(8, toMicros(raw.Start))
+	w.writeI64(9, int64(raw.Duration/time.Microsecond))
+	if len(raw.Tags) > 0 {
+		w.writeListField(10, compactStruct, len(raw.Tags))
+		for k, v := range raw.Tags {
+			writeTag(w, k, v)
+		}
+	}
+	if n := len(raw.Logs) + len(raw.Context.Baggage); n > 0 {
+		w.writeListField(11, compactStruct, n)
+		for k, v := range raw.Context.Baggage {
+			writeLog(w, raw.Start, opentracing.Tags{
+				"event": BaggageLogEvent,
+				"key":   k,
+				"value": v,
+			})
+		}
+		for _, ld := range raw.Logs {
+			fields := opentracing.Tags{}
+			if ld.Event != "" {
+				fields["event"] = ld.Event
+			}
+			if ld.Payload != nil {
+				fields["payload"] = payloadString(ld.Payload)
+			}
+			writeLog(w, ld.Timestamp, fields)
+		}
+	}
+	w.structEnd()
+	return w.bytes()
+}
+
+// writeProcess writes a jaeger.Process struct as field `id`.
+func writeProcess(w *compactWriter, id int16, serviceName string, tags opentracing.Tags) {
+	w.writeStructField(id)
+	w.writeString(1, serviceName)
+	if len(tags) > 0 {
+		w.writeListField(2, compactStruct, len(tags))
+		for k, v := range tags {
+			writeTag(w, k, v)
+		}
+	}
+	w.structEnd()
+}
+
+func writeLog(w *compactWriter, ts time.Time, fields opentracing.Tags) {
+	w.structBegin()
+	w.writeI64(1, toMicros(ts))
+	w.writeListField(2, compactStruct, len(fields))
+	for k, v := range fields {
+		writeTag(w, k, v)
+	}
+	w.structEnd()
+}
+
+// writeTag writes a jaeger.Tag struct as a list element.
+func writeTag(w *compactWriter, key string, value interface{}) {
+	w.structBegin()
+	w.writeString(1, key)
+	switch v := value.(type) {
+	case string:
+		w.writeI32(2, tagTypeString)
+		w.writeString(3, v)
+	case bool:
+		w.writeI32(2, tagTypeBool)
+		w.writeBool(5, v)
+	case float32:
+		w.writeI32(2, tagTypeDouble)
+		w.writeDouble(4, float64(v))
+	case float64:
+		w.writeI32(2, tagTypeDouble)
+		w.writeDouble(4, v)
+	case int, int8, int16, int32, int64:
+		w.writeI32(2, tagTypeLong)
+		w.writeI64(6, reflect.ValueOf(v).Int())
+	case uint, uint8, uint16, uint32, uint64:
+		w.writeI32(2, tagTypeLong)
+		w.writeI64(6, int64(reflect.ValueOf(v).Uint()))
+	case []byte:
+		w.writeI32(2, tagTypeBinary)
+		w.writeBinary(7, v)
+	default:
+		w.writeI32(2, tagTypeString)
+		w.writeString(3, fmt.Sprint(v))
+	}
+	w.structEnd()
+}
+
+func payloadString(payload interface{}) string {
+	if s, ok := payload.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Sprint(payload)
+	}
+	return string(encoded)
+}
+
+func toMicros(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
@@ -0,0 +1,233 @@
+// Package jsonl writes finished spans as newline-delimited JSON, one span per
+// line, and reads them back.
+//
+// Spans from a basictracer can be exported via a processor.Batcher or
+// directly as a recorder.SpanRecorder; spans from a mocktracer via
+// MockSpan.RawSpan().
+package jsonl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Span is the JSON representation of a recorder.RawSpan. IDs are encoded as
+// lowercase hex strings.
+type Span struct {
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Sampled      bool                   `json:"sampled"`
+	LocalRoot    bool                   `json:"local_root,omitempty"`
+	Operation    string                 `json:"operation"`
+	Start        time.Time              `json:"start"`
+	Finish       time.Time              `json:"finish"`
+	Tags         map[string]interface{} `json:"tags,omitempty"`
+	Baggage      map[string]string      `json:"baggage,omitempty"`
+	Logs         []Log                  `json:"logs,omitempty"`
+}
+
+// Log is the JSON representation of an opentracing.LogData.
+type Log struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Event     string      `json:"event,omitempty"`
+	Payload   interface{} `json:"payload,omitempty"`
+}
+
+// FromRawSpan converts `raw` to its JSON representation. Tag values and log
+// payloads that cannot be encoded as JSON are replaced by their fmt.Sprint()
+// form.
+func FromRawSpan(raw recorder.RawSpan) Span {
+	span := Span{
+		TraceID:   raw.Context.TraceID.String(),
+		SpanID:    formatID(raw.Context.SpanID),
+		Sampled:   raw.Context.Sampled,
+		LocalRoot: raw.LocalRoot,
+		Operation: raw.Operation,
+		Start:     raw.Start,
+		Finish:    raw.Finish(),
+		Baggage:   raw.Context.Baggage,
+	}
+	if raw.ParentSpanID != 0 {
+		span.ParentSpanID = formatID(raw.ParentSpanID)
+	}
+	if len(raw.Tags) > 0 {
+		span.Tags = make(map[string]interface{}, len(raw.Tags))
+		for k, v := range raw.Tags {
+			span.Tags[k] = jsonValue(v)
+		}
+	}
+	for _, ld := range raw.Logs {
+		span.Logs = append(span.Logs, Log{
+			Timestamp: ld.Timestamp,
+			Event:     ld.Event,
+			Payload:   jsonValue(ld.Payload),
+		})
+	}
+	return span
+}
+
+// RawSpan converts `s` back to a recorder.RawSpan. Tag values and payloads
+// have the types produced by encoding/json (e.g., numbers are float64).
+func (s Span) RawSpan() (recorder.RawSpan, error) {
+	raw := recorder.RawSpan{
+		LocalRoot: s.LocalRoot,
+		Operation: s.Operation,
+		Start:     s.Start,
+		Duration:  s.Finish.Sub(s.Start),
+		Tags:      opentracing.Tags(s.Tags),
+	}
+	var err error
+	if raw.Context.TraceID, err = parseTraceID(s.TraceID); err != nil {
+		return raw, err
+	}
+	if raw.Context.SpanID, err = strconv.ParseUint(s.SpanID, 16, 64); err != nil {
+		return raw, err
+	}
+	if s.ParentSpanID != "" {
+		if raw.ParentSpanID, err = strconv.ParseUint(s.ParentSpanID, 16, 64); err != nil {
+			return raw, err
+		}
+	}
+	raw.Context.Sampled = s.Sampled
+	raw.Context.Baggage = s.Baggage
+	for _, l := range s.Logs {
+		raw.Logs = append(raw.Logs, opentracing.LogData{
+			Timestamp: l.Timestamp,
+			Event:     l.Event,
+			Payload:   l.Payload,
+		})
+	}
+	return raw, nil
+}
+
+func formatID(id uint64) string {
+	return fmt.Sprintf("%016x", id)
+}
+
+func parseTraceID(s string) (recorder.TraceID, error) {
+	var id recorder.TraceID
+	var err error
+	if len(s) > 16 {
+		if id.High, err = strconv.ParseUint(s[:len(s)-16], 16, 64); err != nil {
+			return id, err
+		}
+		s = s[len(s)-16:]
+	}
+	id.Low, err = strconv.ParseUint(s, 16, 64)
+	return id, err
+}
+
+func jsonValue(v interface{}) interface{} {
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return v
+}
+
+// Exporter writes spans to an io.Writer, one JSON object per line. Each line
+// is written with a single Write call.
+//
+// Exporter implements both processor.Exporter and recorder.SpanRecorder.
+type Exporter struct {
+	lock   sync.Mutex
+	writer io.Writer
+
+	// OnError, if non-nil, is called with write errors encountered by
+	// RecordSpan (ExportSpans returns them instead).
+	OnError func(err error)
+}
+
+// NewExporter returns an Exporter that writes to `w`. See RotatingFile for
+// a size- or time-rotated file.
+func NewExporter(w io.Writer) *Exporter {
+	return &Exporter{writer: w}
+}
+
+// ExportSpans writes `spans` in order and returns the first error
+// encountered.
+func (e *Exporter) ExportSpans(spans []recorder.RawSpan) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, raw := range spans {
+		line, err := json.Marshal(FromRawSpan(raw))
+		if err != nil {
+			return err
+		}
+		if _, err := e.writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordSpan belongs to the recorder.SpanRecorder interface.
+func (e *Exporter) RecordSpan(span recorder.RawSpan) {
+	if err := e.ExportSpans([]recorder.RawSpan{span}); err != nil && e.OnError != nil {
+		e.OnError(err)
+	}
+}
+
+// Decoder reads spans written by an Exporter.
+type Decoder struct {
+	reader *bufio.Reader
+	line   int
+	err    error
+}
+
+// maxLineBytes bounds the size of a single span line accepted by a Decoder.
+const maxLineBytes = 16 << 20
+
+// NewDecoder returns a Decoder that reads from `r`.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{reader: bufio.NewReader(r)}
+}
+
+// Decode returns the next span, or io.EOF once the input is exhausted.
+// Blank lines are skipped. A line longer than 16MiB makes Decode return
+// bufio.ErrTooLong, as does every later call.
+func (d *Decoder) Decode() (Span, error) {
+	for d.err == nil {
+		line, err := d.readLine()
+		if err != nil {
+			d.err = err
+			break
+		}
+		d.line++
+		if len(line) == 0 {
+			continue
+		}
+		var span Span
+		if err := json.Unmarshal(line, &span); err != nil {
+			return Span{}, fmt.Errorf("jsonl: line %d: %v", d.line, err)
+		}
+		return span, nil
+	}
+	return Span{}, d.err
+}
+
+// readLine returns the next line, without its end of line marker.
+func (d *Decoder) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, isPrefix, err := d.reader.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line)+len(chunk) > maxLineBytes {
+			return nil, bufio.ErrTooLong
+		}
+		line = append(line, chunk...)
+		if !isPrefix {
+			return line, nil
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package jsonl
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func TestRoundTrip(t *testing.T) {
+	start := time.Date(2016, 6, 1, 12, 0, 0, 0, time.UTC)
+	raw := recorder.RawSpan{
+		Context: recorder.SpanContext{
+			TraceID: recorder.TraceID{High: 1, Low: 2},
+			SpanID:  3,
+			Sampled: true,
+			Baggage: map[string]string{"user": "alice"},
+		},
+		ParentSpanID: 4,
+		Operation:    "GetFeed",
+		Start:        start,
+		Duration:     time.Second,
+		Tags:         opentracing.Tags{"component": "http"},
+		Logs: []opentracing.LogData{
+			{Timestamp: start, Event: "payload", Payload: map[string]interface{}{"rows": 3.0}},
+			{Timestamp: start, Event: "unencodable", Payload: make(chan int)},
+		},
+	}
+	buf := &bytes.Buffer{}
+	if err := NewExporter(buf).ExportSpans([]recorder.RawSpan{raw, raw}); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Fatalf("Wrote %v lines, expected 2", lines)
+	}
+
+	dec := NewDecoder(buf)
+	span, err := dec.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if span.TraceID != "00000000000000010000000000000002" || span.ParentSpanID != "0000000000000004" {
+		t.Errorf("Unexpected IDs: %+v", span)
+	}
+	decoded, err := span.RawSpan()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Context.TraceID != raw.Context.TraceID || decoded.ParentSpanID != 4 || decoded.Duration != time.Second {
+		t.Errorf("Unexpected decoded span: %+v", decoded)
+	}
+	if !reflect.DeepEqual(decoded.Logs[0].Payload, raw.Logs[0].Payload) {
+		t.Errorf("Payload %#v, expected %#v", decoded.Logs[0].Payload, raw.Logs[0].Payload)
+	}
+	if _, ok := decoded.Logs[1].Payload.(string); !ok {
+		t.Errorf("Expected unencodable payload to be stringified, got %#v", decoded.Logs[1].Payload)
+	}
+	if _, err := dec.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestDecoderLines(t *testing.T) {
+	long := `{"operation":"` + strings.Repeat("x", 10000) + `"}`
+	dec := NewDecoder(strings.NewReader("\r\n" + long + "\r\n\n" + `{"operation":"last"}`))
+	for _, expected := range []string{strings.Repeat("x", 10000), "last"} {
+		span, err := dec.Decode()
+		if err != nil || span.Operation != expected {
+			t.Fatalf("Unexpected span %.20q, %v", span.Operation, err)
+		}
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestMockSpans(t *testing.T) {
+	tracer := mocktracer.New()
+	parent := tracer.StartSpan("parent")
+	opentracing.StartChildSpan(parent, "child").Finish()
+	parent.Finish()
+
+	buf := &bytes.Buffer{}
+	exporter := NewExporter(buf)
+	for _, s := range tracer.FinishedSpans {
+		exporter.RecordSpan(s.RawSpan())
+	}
+	dec := NewDecoder(buf)
+	child, _ := dec.Decode()
+	root, _ := dec.Decode()
+	if child.Operation != "child" || child.ParentSpanID != root.SpanID || !root.LocalRoot {
+		t.Errorf("Unexpected spans: %+v, %+v", child, root)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "spans.jsonl")
+
+	f, err := NewRotatingFile(path, RotateOptions{MaxBytes: 10, MaxAge: time.Minute, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1000, 0)
+	f.now = func() time.Time { return now }
+	f.openedAt = now
+
+	write := func(s string) {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Millisecond)
+	}
+	write("12345\n")
+	write("12345\n") // size rotation
+	write("1\n")     // fits
+	now = now.Add(time.Minute)
+	write("2\n")         // age rotation
+	write("123456789\n") // size rotation, prunes the oldest backup
+	f.Close()
+
+	backups, _ := filepath.Glob(path + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("Found backups %v, expected 2", backups)
+	}
+	contents, _ := ioutil.ReadFile(backups[0])
+	if string(contents) != "12345\n1\n" {
+		t.Errorf("Unexpected oldest backup contents %q", contents)
+	}
+	contents, _ = ioutil.ReadFile(path)
+	if string(contents) != "123456789\n" {
+		t.Errorf("Unexpected active file contents %q", contents)
+	}
+}
+
+func TestRotatingFileOpenFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "spans.jsonl")
+
+	f, err := NewRotatingFile(path, RotateOptions{MaxBytes: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte("12345\n")); err != nil {
+		t.Fatal(err)
+	}
+	errOpen := errors.New("open failed")
+	f.openFile = func(string, int, os.FileMode) (*os.File, error) { return nil, errOpen }
+	if _, err := f.Write([]byte("12345\n")); err != errOpen {
+		t.Errorf("Expected the open error, got %v", err)
+	}
+	f.openFile = os.OpenFile
+	if _, err := f.Write([]byte("1\n")); err != nil {
+		t.Errorf("Expected the file to recover, got %v", err)
+	}
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "12345\n1\n" {
+		t.Errorf("Unexpected active file contents %q", contents)
+	}
+}
@@ -0,0 +1,148 @@
+package jsonl
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RotateOptions configures a RotatingFile. Zero values disable the
+// corresponding limit.
+type RotateOptions struct {
+	// MaxBytes rotates the file before a write would make it exceed this
+	// size. A single write larger than MaxBytes still goes to a fresh file.
+	MaxBytes int64
+
+	// MaxAge rotates the file once it has been open for this long.
+	MaxAge time.Duration
+
+	// MaxBackups bounds the number of rotated files kept next to the active
+	// one; the oldest are removed first.
+	MaxBackups int
+}
+
+// backupTimeFormat sorts lexicographically in chronological order.
+const backupTimeFormat = "20060102T150405.000000000"
+
+// RotatingFile is an io.WriteCloser that appends to the file at a given path
+// and rotates it by size and/or age. Rotated files are renamed to
+// "<path>.<timestamp>".
+//
+// Writes are never split across files, so an Exporter writing to a
+// RotatingFile always produces whole lines in every file.
+type RotatingFile struct {
+	path     string
+	options  RotateOptions
+	now      func() time.Time
+	openFile func(name string, flag int, perm os.FileMode) (*os.File, error)
+
+	lock     sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+// NewRotatingFile opens (or creates) the file at `path` for appending.
+func NewRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:     path,
+		options:  opts,
+		now:      time.Now,
+		openFile: os.OpenFile,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write belongs to the io.Writer interface.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the active file.
+func (f *RotatingFile) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.file.Close()
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.options.MaxBytes > 0 && f.size+n > f.options.MaxBytes {
+		return true
+	}
+	return f.options.MaxAge > 0 && f.now().Sub(f.openedAt) >= f.options.MaxAge
+}
+
+func (f *RotatingFile) open() error {
+	file, err := f.openFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = f.now()
+	return nil
+}
+
+// rotate keeps writing to the current file if the new one cannot be opened,
+// so that a transient failure does not break every later Write.
+func (f *RotatingFile) rotate() error {
+	backup := f.path + "." + f.now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(f.path, backup); err != nil {
+		return err
+	}
+	old := f.file
+	if err := f.open(); err != nil {
+		os.Rename(backup, f.path)
+		return err
+	}
+	if err := old.Close(); err != nil {
+		return err
+	}
+	return f.prune()
+}
+
+func (f *RotatingFile) prune() error {
+	if f.options.MaxBackups <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, m := range matches {
+		if _, err := time.Parse(backupTimeFormat, m[len(f.path)+1:]); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	sort.Strings(backups)
+	for len(backups) > f.options.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
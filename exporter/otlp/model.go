@@ -0,0 +1,173 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// OTLP Span.SpanKind values.
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+	spanKindClient   = 3
+	spanKindProducer = 4
+	spanKindConsumer = 5
+)
+
+// OTLP Status.StatusCode values.
+const (
+	statusCodeError = 2
+)
+
+// ScopeName is the instrumentation scope reported with every span.
+const ScopeName = "github.com/opentracing/opentracing-go"
+
+// encodeRequest returns an encoded ExportTraceServiceRequest holding
+// `spans` for a single resource.
+func encodeRequest(resource opentracing.Tags, spans []recorder.RawSpan) []byte {
+	res := &protoBuffer{}
+	writeAttributes(res, 1, resource)
+
+	scope := &protoBuffer{}
+	scope.stringField(1, ScopeName)
+
+	scopeSpans := &protoBuffer{}
+	scopeSpans.messageField(1, scope)
+	for _, raw := range spans {
+		scopeSpans.messageField(2, encodeSpan(raw))
+	}
+
+	resourceSpans := &protoBuffer{}
+	resourceSpans.messageField(1, res)
+	resourceSpans.messageField(2, scopeSpans)
+
+	req := &protoBuffer{}
+	req.messageField(1, resourceSpans)
+	return req.buf
+}
+
+// encodeSpan encodes an opentelemetry.proto.trace.v1.Span. The
+// `ext.SpanKind` and `ext.Error` tags become the span kind and status; the
+// other tags become attributes and LogData become events.
+func encodeSpan(raw recorder.RawSpan) *protoBuffer {
+	s := &protoBuffer{}
+	var traceID [16]byte
+	binary.BigEndian.PutUint64(traceID[:8], raw.Context.TraceID.High)
+	binary.BigEndian.PutUint64(traceID[8:], raw.Context.TraceID.Low)
+	s.bytesField(1, traceID[:])
+	s.bytesField(2, spanIDBytes(raw.Context.SpanID))
+	if raw.ParentSpanID != 0 {
+		s.bytesField(4, spanIDBytes(raw.ParentSpanID))
+	}
+	s.stringField(5, raw.Operation)
+	s.uint64Field(6, spanKind(raw.Tags[string(ext.SpanKind)]))
+	s.fixed64Field(7, uint64(raw.Start.UnixNano()))
+	s.fixed64Field(8, uint64(raw.Finish().UnixNano()))
+
+	attributes := make(opentracing.Tags, len(raw.Tags))
+	for k, v := range raw.Tags {
+		if k != string(ext.SpanKind) && k != string(ext.Error) {
+			attributes[k] = v
+		}
+	}
+	writeAttributes(s, 9, attributes)
+
+	for _, ld := range raw.Logs {
+		event := &protoBuffer{}
+		event.fixed64Field(1, uint64(ld.Timestamp.UnixNano()))
+		name := ld.Event
+		if name == "" {
+			name = "log"
+		}
+		event.stringField(2, name)
+		if ld.Payload != nil {
+			writeAttributes(event, 3, opentracing.Tags{"payload": payloadValue(ld.Payload)})
+		}
+		s.messageField(11, event)
+	}
+
+	if isError, _ := raw.Tags[string(ext.Error)].(bool); isError {
+		status := &protoBuffer{}
+		status.uint64Field(3, statusCodeError)
+		s.messageField(15, status)
+	}
+	return s
+}
+
+func spanIDBytes(id uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], id)
+	return b[:]
+}
+
+func spanKind(v interface{}) uint64 {
+	if v == nil {
+		return spanKindInternal
+	}
+	switch strings.ToLower(fmt.Sprint(v)) {
+	case string(ext.SpanKindRPCServer):
+		return spanKindServer
+	case string(ext.SpanKindRPCClient):
+		return spanKindClient
+	case "producer":
+		return spanKindProducer
+	case "consumer":
+		return spanKindConsumer
+	}
+	return spanKindInternal
+}
+
+// writeAttributes writes `tags` as repeated KeyValue field `field`.
+func writeAttributes(b *protoBuffer, field int, tags opentracing.Tags) {
+	for k, v := range tags {
+		kv := &protoBuffer{}
+		kv.stringField(1, k)
+		kv.messageField(2, anyValue(v))
+		b.messageField(field, kv)
+	}
+}
+
+// anyValue encodes an opentelemetry.proto.common.v1.AnyValue.
+func anyValue(v interface{}) *protoBuffer {
+	b := &protoBuffer{}
+	switch v := v.(type) {
+	case string:
+		b.bytesField(1, []byte(v))
+	case bool:
+		b.boolField(2, v)
+	case int, int8, int16, int32, int64:
+		b.tag(3, wireVarint)
+		b.varint(uint64(reflect.ValueOf(v).Int()))
+	case uint, uint8, uint16, uint32, uint64:
+		b.tag(3, wireVarint)
+		b.varint(reflect.ValueOf(v).Uint())
+	case float32:
+		b.doubleField(4, float64(v))
+	case float64:
+		b.doubleField(4, v)
+	case []byte:
+		b.bytesField(7, v)
+	default:
+		b.bytesField(1, []byte(fmt.Sprint(v)))
+	}
+	return b
+}
+
+func payloadValue(payload interface{}) interface{} {
+	switch payload.(type) {
+	case string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, []byte:
+		return payload
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Sprint(payload)
+	}
+	return string(encoded)
+}
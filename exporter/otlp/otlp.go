@@ -0,0 +1,113 @@
+// Package otlp exports finished spans to an OpenTelemetry collector using
+// OTLP/HTTP with gzip-compressed protobuf payloads, so that services
+// instrumented with this API can report to OpenTelemetry backends.
+//
+// The Exporter sends one request per ExportSpans call; pair it with a
+// processor.Batcher to batch spans from a basictracer.
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// DefaultURL is the OTLP/HTTP traces endpoint of a collector running on the
+// local host.
+const DefaultURL = "http://localhost:4318/v1/traces"
+
+// ServiceNameKey is the resource attribute holding Options.ServiceName.
+const ServiceNameKey = "service.name"
+
+// Options configures an Exporter.
+type Options struct {
+	// URL is the collector's traces endpoint. Defaults to DefaultURL.
+	URL string
+
+	// ServiceName is reported as the "service.name" resource attribute.
+	ServiceName string
+
+	// ResourceAttributes are additional attributes describing this process.
+	ResourceAttributes opentracing.Tags
+
+	// Headers are added to every request (e.g., for authentication).
+	Headers map[string]string
+
+	// Client is used to send requests. Defaults to a client with a ten
+	// second timeout.
+	Client *http.Client
+
+	// DisableCompression sends uncompressed payloads.
+	DisableCompression bool
+}
+
+// Exporter sends spans to an OTLP/HTTP collector. It implements
+// processor.Exporter.
+type Exporter struct {
+	options  Options
+	resource opentracing.Tags
+}
+
+// NewExporter returns an Exporter configured by `opts`.
+func NewExporter(opts Options) *Exporter {
+	if opts.URL == "" {
+		opts.URL = DefaultURL
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resource := opentracing.Tags{}
+	resource.Merge(opts.ResourceAttributes)
+	if opts.ServiceName != "" {
+		resource[ServiceNameKey] = opts.ServiceName
+	}
+	return &Exporter{options: opts, resource: resource}
+}
+
+// ExportSpans sends `spans` to the collector in a single request.
+func (e *Exporter) ExportSpans(spans []recorder.RawSpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	payload := encodeRequest(e.resource, spans)
+
+	body := &bytes.Buffer{}
+	if e.options.DisableCompression {
+		body.Write(payload)
+	} else {
+		gz := gzip.NewWriter(body)
+		gz.Write(payload)
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest("POST", e.options.URL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	if !e.options.DisableCompression {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range e.options.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.options.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp: collector returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package otlp
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// message is a decoded protobuf message: field number to values, where
+// varints and fixed64s are uint64 and length-delimited fields are []byte.
+type message map[int][]interface{}
+
+func decode(t *testing.T, buf []byte) message {
+	m := message{}
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		buf = buf[n:]
+		field, wireType := int(key>>3), int(key&7)
+		switch wireType {
+		case wireVarint:
+			v, n := binary.Uvarint(buf)
+			buf = buf[n:]
+			m[field] = append(m[field], v)
+		case wireFixed64:
+			m[field] = append(m[field], binary.LittleEndian.Uint64(buf))
+			buf = buf[8:]
+		case wireBytes:
+			l, n := binary.Uvarint(buf)
+			buf = buf[n:]
+			m[field] = append(m[field], buf[:l])
+			buf = buf[l:]
+		default:
+			t.Fatalf("Unexpected wire type %v", wireType)
+		}
+	}
+	return m
+}
+
+func (m message) sub(t *testing.T, field int) message {
+	return decode(t, m[field][0].([]byte))
+}
+
+func (m message) str(field int) string {
+	if len(m[field]) == 0 {
+		return ""
+	}
+	return string(m[field][0].([]byte))
+}
+
+func attributes(t *testing.T, m message, field int) map[string]interface{} {
+	rval := map[string]interface{}{}
+	for _, raw := range m[field] {
+		kv := decode(t, raw.([]byte))
+		value := kv.sub(t, 2)
+		switch {
+		case value[1] != nil:
+			rval[kv.str(1)] = value.str(1)
+		case value[2] != nil:
+			rval[kv.str(1)] = value[2][0] == uint64(1)
+		case value[3] != nil:
+			rval[kv.str(1)] = int64(value[3][0].(uint64))
+		case value[4] != nil:
+			rval[kv.str(1)] = math.Float64frombits(value[4][0].(uint64))
+		}
+	}
+	return rval
+}
+
+func TestExportSpans(t *testing.T) {
+	var body []byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("Unexpected request %v %v", r.URL, r.Header)
+		}
+		if r.Header.Get("Authorization") != "token" {
+			t.Errorf("Missing Authorization header")
+		}
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ = ioutil.ReadAll(gz)
+	}))
+	defer collector.Close()
+
+	exporter := NewExporter(Options{
+		URL:                collector.URL + "/v1/traces",
+		ServiceName:        "frontend",
+		ResourceAttributes: opentracing.Tags{"host.name": "pod-1"},
+		Headers:            map[string]string{"Authorization": "token"},
+	})
+	start := time.Unix(1000, 0)
+	err := exporter.ExportSpans([]recorder.RawSpan{{
+		Context:      recorder.SpanContext{TraceID: recorder.TraceID{High: 1, Low: 2}, SpanID: 3},
+		ParentSpanID: 4,
+		Operation:    "GetFeed",
+		Start:        start,
+		Duration:     time.Second,
+		Tags: opentracing.Tags{
+			string(ext.SpanKind):       ext.SpanKindRPCServer,
+			string(ext.Error):          true,
+			string(ext.HTTPStatusCode): uint16(500),
+			"ratio":                    0.5,
+		},
+		Logs: []opentracing.LogData{{Timestamp: start, Event: "retry", Payload: map[string]int{"n": 2}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := decode(t, body)
+	resourceSpans := req.sub(t, 1)
+	resource := attributes(t, resourceSpans.sub(t, 1), 1)
+	if resource[ServiceNameKey] != "frontend" || resource["host.name"] != "pod-1" {
+		t.Errorf("Unexpected resource %v", resource)
+	}
+	scopeSpans := resourceSpans.sub(t, 2)
+	if name := scopeSpans.sub(t, 1).str(1); name != ScopeName {
+		t.Errorf("Unexpected scope %q", name)
+	}
+	span := scopeSpans.sub(t, 2)
+	traceID := span[1][0].([]byte)
+	if binary.BigEndian.Uint64(traceID[:8]) != 1 || binary.BigEndian.Uint64(traceID[8:]) != 2 {
+		t.Errorf("Unexpected trace ID % x", traceID)
+	}
+	if binary.BigEndian.Uint64(span[2][0].([]byte)) != 3 || binary.BigEndian.Uint64(span[4][0].([]byte)) != 4 {
+		t.Errorf("Unexpected span or parent ID")
+	}
+	if span.str(5) != "GetFeed" || span[6][0] != uint64(spanKindServer) {
+		t.Errorf("Unexpected name or kind: %q %v", span.str(5), span[6])
+	}
+	if span[7][0] != uint64(start.UnixNano()) || span[8][0] != uint64(start.Add(time.Second).UnixNano()) {
+		t.Errorf("Unexpected timestamps %v %v", span[7], span[8])
+	}
+	attrs := attributes(t, span, 9)
+	expected := map[string]interface{}{"http.status_code": int64(500), "ratio": 0.5}
+	if len(attrs) != len(expected) || attrs["http.status_code"] != expected["http.status_code"] || attrs["ratio"] != expected["ratio"] {
+		t.Errorf("Unexpected attributes %v", attrs)
+	}
+	event := span.sub(t, 11)
+	if event.str(2) != "retry" || attributes(t, event, 3)["payload"] != `{"n":2}` {
+		t.Errorf("Unexpected event %v", event)
+	}
+	if status := span.sub(t, 15); status[3][0] != uint64(statusCodeError) {
+		t.Errorf("Unexpected status %v", status)
+	}
+}
+
+func TestExportError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+	exporter := NewExporter(Options{URL: collector.URL, DisableCompression: true})
+	if err := exporter.ExportSpans([]recorder.RawSpan{{}}); err == nil {
+		t.Error("Expected an error")
+	}
+}
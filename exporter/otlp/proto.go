@@ -0,0 +1,84 @@
+package otlp
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Protocol buffer wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// protoBuffer appends protocol buffer fields to a byte slice. Embedded
+// messages are written by encoding them into their own protoBuffer first.
+type protoBuffer struct {
+	buf []byte
+}
+
+func (b *protoBuffer) tag(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		b.buf = append(b.buf, byte(v)|0x80)
+		v >>= 7
+	}
+	b.buf = append(b.buf, byte(v))
+}
+
+func (b *protoBuffer) uint64Field(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.tag(field, wireVarint)
+	b.varint(v)
+}
+
+func (b *protoBuffer) boolField(field int, v bool) {
+	b.tag(field, wireVarint)
+	if v {
+		b.varint(1)
+	} else {
+		b.varint(0)
+	}
+}
+
+func (b *protoBuffer) fixed64Field(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	b.tag(field, wireFixed64)
+	var raw [8]byte
+	binary.LittleEndian.PutUint64(raw[:], v)
+	b.buf = append(b.buf, raw[:]...)
+}
+
+func (b *protoBuffer) doubleField(field int, v float64) {
+	b.tag(field, wireFixed64)
+	var raw [8]byte
+	binary.LittleEndian.PutUint64(raw[:], math.Float64bits(v))
+	b.buf = append(b.buf, raw[:]...)
+}
+
+func (b *protoBuffer) bytesField(field int, v []byte) {
+	b.tag(field, wireBytes)
+	b.varint(uint64(len(v)))
+	b.buf = append(b.buf, v...)
+}
+
+func (b *protoBuffer) stringField(field int, v string) {
+	if v == "" {
+		return
+	}
+	b.tag(field, wireBytes)
+	b.varint(uint64(len(v)))
+	b.buf = append(b.buf, v...)
+}
+
+func (b *protoBuffer) messageField(field int, m *protoBuffer) {
+	b.bytesField(field, m.buf)
+}
@@ -0,0 +1,139 @@
+package zipkin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Endpoint is the Zipkin v2 representation of a network node.
+type Endpoint struct {
+	ServiceName string `json:"serviceName,omitempty"`
+	IPv4        string `json:"ipv4,omitempty"`
+	IPv6        string `json:"ipv6,omitempty"`
+	Port        uint16 `json:"port,omitempty"`
+}
+
+// Annotation is a timestamped event within a Zipkin span.
+type Annotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// Span is the Zipkin v2 JSON representation of a span. Timestamps and
+// durations are in microseconds.
+type Span struct {
+	TraceID        string            `json:"traceId"`
+	ID             string            `json:"id"`
+	ParentID       string            `json:"parentId,omitempty"`
+	Name           string            `json:"name,omitempty"`
+	Kind           string            `json:"kind,omitempty"`
+	Timestamp      int64             `json:"timestamp,omitempty"`
+	Duration       int64             `json:"duration,omitempty"`
+	LocalEndpoint  *Endpoint         `json:"localEndpoint,omitempty"`
+	RemoteEndpoint *Endpoint         `json:"remoteEndpoint,omitempty"`
+	Annotations    []Annotation      `json:"annotations,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+}
+
+// FromRawSpan converts `raw` to a Zipkin span reported by `local`.
+//
+// The `ext.Peer*` tags become the remote endpoint, `ext.SpanKind` becomes
+// the kind, and LogData become annotations; the remaining tags are
+// stringified.
+func FromRawSpan(raw recorder.RawSpan, local *Endpoint) Span {
+	span := Span{
+		TraceID:       raw.Context.TraceID.String(),
+		ID:            fmt.Sprintf("%016x", raw.Context.SpanID),
+		Name:          raw.Operation,
+		Timestamp:     toMicros(raw.Start),
+		Duration:      int64(raw.Duration / time.Microsecond),
+		LocalEndpoint: local,
+	}
+	if raw.ParentSpanID != 0 {
+		span.ParentID = fmt.Sprintf("%016x", raw.ParentSpanID)
+	}
+	// Zipkin interprets a zero duration as "unknown".
+	if span.Duration == 0 && raw.Duration > 0 {
+		span.Duration = 1
+	}
+
+	remote := &Endpoint{}
+	for k, v := range raw.Tags {
+		switch k {
+		case string(ext.SpanKind):
+			span.Kind = kind(v)
+			continue
+		case string(ext.PeerService):
+			remote.ServiceName = fmt.Sprint(v)
+			continue
+		case string(ext.PeerHostIPv4):
+			if ip, ok := v.(uint32); ok {
+				remote.IPv4 = net.IPv4(byte(ip>>24), byte(ip>>16), byte(ip>>8), byte(ip)).String()
+				continue
+			}
+			remote.IPv4 = fmt.Sprint(v)
+			continue
+		case string(ext.PeerHostIPv6):
+			remote.IPv6 = fmt.Sprint(v)
+			continue
+		case string(ext.PeerPort):
+			if port, ok := v.(uint16); ok {
+				remote.Port = port
+				continue
+			}
+		}
+		if span.Tags == nil {
+			span.Tags = map[string]string{}
+		}
+		span.Tags[k] = fmt.Sprint(v)
+	}
+	if *remote != (Endpoint{}) {
+		span.RemoteEndpoint = remote
+	}
+
+	for _, ld := range raw.Logs {
+		span.Annotations = append(span.Annotations, Annotation{
+			Timestamp: toMicros(ld.Timestamp),
+			Value:     annotationValue(ld.Event, ld.Payload),
+		})
+	}
+	return span
+}
+
+func kind(v interface{}) string {
+	switch strings.ToLower(fmt.Sprint(v)) {
+	case string(ext.SpanKindRPCClient):
+		return "CLIENT"
+	case string(ext.SpanKindRPCServer):
+		return "SERVER"
+	case "producer":
+		return "PRODUCER"
+	case "consumer":
+		return "CONSUMER"
+	}
+	return ""
+}
+
+func annotationValue(event string, payload interface{}) string {
+	if payload == nil {
+		return event
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		encoded = []byte(fmt.Sprint(payload))
+	}
+	if event == "" {
+		return string(encoded)
+	}
+	return event + " " + string(encoded)
+}
+
+func toMicros(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
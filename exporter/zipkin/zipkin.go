@@ -0,0 +1,124 @@
+// Package zipkin exports finished spans to a Zipkin collector using the
+// Zipkin v2 JSON format over HTTP.
+//
+// The Exporter sends one batch per ExportSpans call; pair it with a
+// processor.Batcher to batch spans from a basictracer:
+//
+//	exporter := zipkin.NewExporter(zipkin.Options{
+//	    URL:           "http://localhost:9411/api/v2/spans",
+//	    LocalEndpoint: &zipkin.Endpoint{ServiceName: "frontend"},
+//	})
+//	tracer := basictracer.New(processor.NewBatcher(exporter, processor.BatchOptions{}))
+package zipkin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Options configures an Exporter.
+type Options struct {
+	// URL is the collector's span endpoint, typically
+	// "http://<host>:9411/api/v2/spans". Required.
+	URL string
+
+	// LocalEndpoint describes this service; it is attached to every span.
+	LocalEndpoint *Endpoint
+
+	// Client is used to send requests. Defaults to a client with a ten
+	// second timeout.
+	Client *http.Client
+
+	// MaxRetries is the number of times a failed request is retried.
+	// Requests are retried on transport errors, 429 and 5xx responses.
+	// Defaults to 3; a negative value disables retries.
+	MaxRetries int
+
+	// InitialBackoff is the delay before the first retry; it doubles with
+	// every subsequent retry up to MaxBackoff. They default to 100ms and 5s.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Exporter POSTs batches of spans to a Zipkin collector. It implements
+// processor.Exporter.
+type Exporter struct {
+	options Options
+	sleep   func(time.Duration)
+}
+
+// NewExporter returns an Exporter configured by `opts`.
+func NewExporter(opts Options) *Exporter {
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = 3
+	}
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = 100 * time.Millisecond
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 5 * time.Second
+	}
+	return &Exporter{options: opts, sleep: time.Sleep}
+}
+
+// ExportSpans sends `spans` to the collector as a single request, retrying
+// with exponential backoff, and returns the last error if every attempt
+// failed.
+func (e *Exporter) ExportSpans(spans []recorder.RawSpan) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	zspans := make([]Span, len(spans))
+	for i, raw := range spans {
+		zspans[i] = FromRawSpan(raw, e.options.LocalEndpoint)
+	}
+	body, err := json.Marshal(zspans)
+	if err != nil {
+		return err
+	}
+
+	backoff := e.options.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := e.post(body)
+		if err == nil || !retry || attempt >= e.options.MaxRetries {
+			return err
+		}
+		e.sleep(backoff)
+		if backoff *= 2; backoff > e.options.MaxBackoff {
+			backoff = e.options.MaxBackoff
+		}
+	}
+}
+
+// post sends one request and reports whether a failure is worth retrying.
+func (e *Exporter) post(body []byte) (retry bool, err error) {
+	req, err := http.NewRequest("POST", e.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := e.options.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("zipkin: collector returned %s", resp.Status)
+	return resp.StatusCode == statusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// statusTooManyRequests is http.StatusTooManyRequests, which needs Go 1.6.
+const statusTooManyRequests = 429
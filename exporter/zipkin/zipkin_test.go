@@ -0,0 +1,112 @@
+package zipkin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func TestFromRawSpan(t *testing.T) {
+	start := time.Unix(1000, 0)
+	raw := recorder.RawSpan{
+		Context:      recorder.SpanContext{TraceID: recorder.TraceID{Low: 1}, SpanID: 2},
+		ParentSpanID: 3,
+		Operation:    "GetFeed",
+		Start:        start,
+		Duration:     1500 * time.Microsecond,
+		Tags: opentracing.Tags{
+			string(ext.SpanKind):     ext.SpanKindRPCClient,
+			string(ext.PeerService):  "feed",
+			string(ext.PeerHostIPv4): uint32(127<<24 | 1),
+			string(ext.PeerPort):     uint16(8080),
+			string(ext.HTTPMethod):   "GET",
+		},
+		Logs: []opentracing.LogData{
+			{Timestamp: start, Event: "retry", Payload: map[string]int{"attempt": 2}},
+		},
+	}
+	span := FromRawSpan(raw, &Endpoint{ServiceName: "frontend"})
+	expected := Span{
+		TraceID:        "0000000000000001",
+		ID:             "0000000000000002",
+		ParentID:       "0000000000000003",
+		Name:           "GetFeed",
+		Kind:           "CLIENT",
+		Timestamp:      1000000000,
+		Duration:       1500,
+		LocalEndpoint:  &Endpoint{ServiceName: "frontend"},
+		RemoteEndpoint: &Endpoint{ServiceName: "feed", IPv4: "127.0.0.1", Port: 8080},
+		Annotations:    []Annotation{{Timestamp: 1000000000, Value: `retry {"attempt":2}`}},
+		Tags:           map[string]string{"http.method": "GET"},
+	}
+	got, _ := json.Marshal(span)
+	want, _ := json.Marshal(expected)
+	if string(got) != string(want) {
+		t.Errorf("FromRawSpan() =\n%s\nexpected\n%s", got, want)
+	}
+}
+
+func TestExporterRetries(t *testing.T) {
+	var (
+		lock     sync.Mutex
+		attempts int
+		received []Span
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected Content-Type %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer collector.Close()
+
+	var backoffs []time.Duration
+	exporter := NewExporter(Options{URL: collector.URL, InitialBackoff: time.Millisecond})
+	exporter.sleep = func(d time.Duration) { backoffs = append(backoffs, d) }
+
+	spans := []recorder.RawSpan{{Operation: "a"}, {Operation: "b"}}
+	if err := exporter.ExportSpans(spans); err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 2 || received[0].Name != "a" {
+		t.Errorf("Unexpected received spans %+v", received)
+	}
+	if len(backoffs) != 2 || backoffs[1] != 2*time.Millisecond {
+		t.Errorf("Unexpected backoffs %v", backoffs)
+	}
+}
+
+func TestExporterDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer collector.Close()
+
+	exporter := NewExporter(Options{URL: collector.URL})
+	exporter.sleep = func(time.Duration) {}
+	if err := exporter.ExportSpans([]recorder.RawSpan{{}}); err == nil {
+		t.Error("Expected an error")
+	}
+	if attempts != 1 {
+		t.Errorf("Made %v attempts, expected 1", attempts)
+	}
+}
@@ -0,0 +1,144 @@
+package faultinject
+
+import (
+	"math/rand"
+	"net/http"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Mutator rewrites a TextMap entry; returning false for `keep` drops it.
+type Mutator func(key, val string) (newKey, newVal string, keep bool)
+
+// DropKeys drops the entries whose key starts with `prefix`, compared
+// case-insensitively.
+func DropKeys(prefix string) Mutator {
+	return func(key, val string) (string, string, bool) {
+		return key, val, !hasPrefixFold(key, prefix)
+	}
+}
+
+// ReplaceValues replaces the value of the entries whose key starts with
+// `prefix` (compared case-insensitively) with `value`.
+func ReplaceValues(prefix, value string) Mutator {
+	return func(key, val string) (string, string, bool) {
+		if hasPrefixFold(key, prefix) {
+			val = value
+		}
+		return key, val, true
+	}
+}
+
+// TruncateValues truncates every value to at most `n` bytes.
+func TruncateValues(n int) Mutator {
+	return func(key, val string) (string, string, bool) {
+		if len(val) > n {
+			val = val[:n]
+		}
+		return key, val, true
+	}
+}
+
+// OversizeValues pads every value to at least `n` bytes.
+func OversizeValues(n int) Mutator {
+	return func(key, val string) (string, string, bool) {
+		if len(val) < n {
+			val += strings.Repeat("x", n-len(val))
+		}
+		return key, val, true
+	}
+}
+
+// TextMapCarrier is implemented by carriers usable for both Inject() and
+// Join() with the TextMap format, like opentracing.HTTPHeaderTextMapCarrier.
+type TextMapCarrier interface {
+	opentracing.TextMapWriter
+	opentracing.TextMapReader
+}
+
+// Carrier applies Mutators, in order, to the entries written to or read from
+// an underlying carrier.
+type Carrier struct {
+	carrier  TextMapCarrier
+	mutators []Mutator
+}
+
+// NewCarrier returns a Carrier wrapping `carrier`.
+func NewCarrier(carrier TextMapCarrier, mutators ...Mutator) *Carrier {
+	return &Carrier{carrier: carrier, mutators: mutators}
+}
+
+func (c *Carrier) mutate(key, val string) (string, string, bool) {
+	keep := true
+	for _, m := range c.mutators {
+		if key, val, keep = m(key, val); !keep {
+			break
+		}
+	}
+	return key, val, keep
+}
+
+// Set belongs to the opentracing.TextMapWriter interface.
+func (c *Carrier) Set(key, val string) {
+	if key, val, keep := c.mutate(key, val); keep {
+		c.carrier.Set(key, val)
+	}
+}
+
+// ForeachKey belongs to the opentracing.TextMapReader interface.
+func (c *Carrier) ForeachKey(handler func(key, val string) error) error {
+	return c.carrier.ForeachKey(func(key, val string) error {
+		if key, val, keep := c.mutate(key, val); keep {
+			return handler(key, val)
+		}
+		return nil
+	})
+}
+
+// garbageKeyPrefixes makes GarbageHeaders likely to collide with the keys
+// used by the tracers of this repository.
+var garbageKeyPrefixes = []string{"", "ot-tracer-", "ot-baggage-", "mockpfx-ids-", "mockpfx-baggage-"}
+
+var garbageValues = []string{
+	"",
+	"%",
+	"%zz",
+	"-1",
+	"0",
+	"18446744073709551616",
+	"ffffffffffffffffffffffffffffffffff",
+	"\x00\xff",
+	"日本語",
+}
+
+// GarbageHeaders adds `n` random entries to `h`, with keys that may look
+// like those of known tracers and values that may be malformed, badly
+// escaped, or up to 64KiB long. The result is determined by `rnd`.
+func GarbageHeaders(h http.Header, rnd *rand.Rand, n int) {
+	for i := 0; i < n; i++ {
+		key := garbageKeyPrefixes[rnd.Intn(len(garbageKeyPrefixes))] + randomString(rnd, 1+rnd.Intn(12))
+		var val string
+		switch rnd.Intn(3) {
+		case 0:
+			val = garbageValues[rnd.Intn(len(garbageValues))]
+		case 1:
+			val = randomString(rnd, rnd.Intn(64))
+		default:
+			val = strings.Repeat(randomString(rnd, 16), 1+rnd.Intn(4096))
+		}
+		h.Add(key, val)
+	}
+}
+
+func randomString(rnd *rand.Rand, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(rnd.Intn(256))
+	}
+	return string(b)
+}
+
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,113 @@
+// Package faultinject helps testing that services survive corrupted,
+// missing or oversized trace propagation data.
+//
+// Tracer wraps an opentracing.Tracer and makes chosen Inject() or Join()
+// calls fail or panic. Carrier wraps a TextMap carrier and mutates the
+// entries that go through it, and GarbageHeaders fills an http.Header with
+// junk to feed through opentracing.HTTPHeaderTextMapCarrier.
+package faultinject
+
+import (
+	"fmt"
+	"sync"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Fault is what happens instead of a call.
+type Fault struct {
+	// Err is returned by the call. It may only be nil for Inject(), which
+	// then silently injects nothing.
+	Err error
+
+	// Panic, if non-nil, makes the call panic with this value instead.
+	Panic interface{}
+}
+
+// Options configures a Tracer.
+type Options struct {
+	// InjectFaults maps call numbers of Inject(), starting at 1, to the Fault
+	// replacing that call.
+	InjectFaults map[int]Fault
+
+	// JoinFaults maps call numbers of Join(), starting at 1, to the Fault
+	// replacing that call.
+	JoinFaults map[int]Fault
+
+	// Every, if non-zero, repeats the faults with that period: call n gets
+	// the fault of call ((n-1) % Every) + 1.
+	Every int
+}
+
+// Tracer forwards to an underlying Tracer, except for the Inject() and Join()
+// calls replaced by a Fault. Note that Spans are those of the underlying
+// Tracer, so `span.Tracer().Inject()` bypasses the faults: call Inject() on
+// the Tracer itself.
+type Tracer struct {
+	opentracing.Tracer
+
+	options Options
+
+	lock    sync.Mutex
+	injects int
+	joins   int
+}
+
+// Wrap returns a Tracer injecting the faults of `opts` into `tracer`. It
+// panics if a Fault of JoinFaults has neither an Err nor a Panic, since
+// Join() must return either a Span or an error.
+func Wrap(tracer opentracing.Tracer, opts Options) *Tracer {
+	for n, fault := range opts.JoinFaults {
+		if fault.Err == nil && fault.Panic == nil {
+			panic(fmt.Sprintf("faultinject: Join fault %d has neither an Err nor a Panic", n))
+		}
+	}
+	return &Tracer{Tracer: tracer, options: opts}
+}
+
+// Calls returns the number of Inject() and Join() calls so far, including
+// those that were replaced by a Fault.
+func (t *Tracer) Calls() (injects, joins int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.injects, t.joins
+}
+
+// Inject belongs to the opentracing.Tracer interface.
+func (t *Tracer) Inject(sp opentracing.Span, format interface{}, carrier interface{}) error {
+	t.lock.Lock()
+	t.injects++
+	fault, ok := t.fault(t.options.InjectFaults, t.injects)
+	t.lock.Unlock()
+	if ok {
+		return fault.apply()
+	}
+	return t.Tracer.Inject(sp, format, carrier)
+}
+
+// Join belongs to the opentracing.Tracer interface.
+func (t *Tracer) Join(operationName string, format interface{}, carrier interface{}) (opentracing.Span, error) {
+	t.lock.Lock()
+	t.joins++
+	fault, ok := t.fault(t.options.JoinFaults, t.joins)
+	t.lock.Unlock()
+	if ok {
+		return nil, fault.apply()
+	}
+	return t.Tracer.Join(operationName, format, carrier)
+}
+
+func (t *Tracer) fault(faults map[int]Fault, call int) (Fault, bool) {
+	if t.options.Every > 0 {
+		call = (call-1)%t.options.Every + 1
+	}
+	fault, ok := faults[call]
+	return fault, ok
+}
+
+func (f Fault) apply() error {
+	if f.Panic != nil {
+		panic(f.Panic)
+	}
+	return f.Err
+}
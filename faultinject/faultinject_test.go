@@ -0,0 +1,110 @@
+package faultinject
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestTracer(t *testing.T) {
+	errCustom := errors.New("custom")
+	tracer := Wrap(mocktracer.New(), Options{
+		InjectFaults: map[int]Fault{2: {Err: opentracing.ErrInvalidCarrier}},
+		JoinFaults: map[int]Fault{
+			1: {Err: errCustom},
+			3: {Panic: "boom"},
+		},
+		Every: 3,
+	})
+	sp := tracer.StartSpan("client")
+	carrier := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+
+	var injectErrs []error
+	for i := 0; i < 4; i++ {
+		injectErrs = append(injectErrs, tracer.Inject(sp, opentracing.TextMap, carrier))
+	}
+	if injectErrs[0] != nil || injectErrs[1] != opentracing.ErrInvalidCarrier || injectErrs[2] != nil || injectErrs[3] != nil {
+		t.Errorf("Unexpected Inject errors %v", injectErrs)
+	}
+
+	join := func() (err error, recovered interface{}) {
+		defer func() { recovered = recover() }()
+		_, err = tracer.Join("server", opentracing.TextMap, carrier)
+		return err, nil
+	}
+	for i, expected := range []struct {
+		err   error
+		panic interface{}
+	}{{errCustom, nil}, {nil, nil}, {nil, "boom"}, {errCustom, nil}} {
+		if err, recovered := join(); err != expected.err || recovered != expected.panic {
+			t.Errorf("Join %d: %v, %v", i+1, err, recovered)
+		}
+	}
+	if injects, joins := tracer.Calls(); injects != 4 || joins != 4 {
+		t.Errorf("Unexpected call counts %v, %v", injects, joins)
+	}
+}
+
+func TestWrapRejectsEmptyJoinFault(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Wrap to panic")
+		}
+	}()
+	Wrap(mocktracer.New(), Options{JoinFaults: map[int]Fault{1: {}}})
+}
+
+func TestCarrier(t *testing.T) {
+	tracer := mocktracer.New()
+	sp := tracer.StartSpan("client")
+	sp.SetBaggageItem("user", "alice")
+
+	for _, test := range []struct {
+		mutators []Mutator
+		err      error
+	}{
+		{nil, nil},
+		{[]Mutator{DropKeys("mockpfx-ids-")}, opentracing.ErrTraceNotFound},
+		{[]Mutator{DropKeys("mockpfx-ids-spanid")}, opentracing.ErrTraceCorrupted},
+		{[]Mutator{ReplaceValues("mockpfx-ids-traceid", "garbage")}, opentracing.ErrTraceCorrupted},
+		{[]Mutator{TruncateValues(0)}, opentracing.ErrTraceCorrupted},
+		{[]Mutator{OversizeValues(1 << 16)}, opentracing.ErrTraceCorrupted},
+	} {
+		h := http.Header{}
+		// Mutate on Inject...
+		if err := tracer.Inject(sp, opentracing.TextMap, NewCarrier(opentracing.HTTPHeaderTextMapCarrier(h), test.mutators...)); err != nil {
+			t.Fatal(err)
+		}
+		_, err := tracer.Join("server", opentracing.TextMap, opentracing.HTTPHeaderTextMapCarrier(h))
+		if err != test.err {
+			t.Errorf("Join after mutated Inject: expected %v, got %v", test.err, err)
+		}
+
+		// ... and on Join.
+		h = http.Header{}
+		tracer.Inject(sp, opentracing.TextMap, opentracing.HTTPHeaderTextMapCarrier(h))
+		_, err = tracer.Join("server", opentracing.TextMap, NewCarrier(opentracing.HTTPHeaderTextMapCarrier(h), test.mutators...))
+		if err != test.err {
+			t.Errorf("Mutated Join: expected %v, got %v", test.err, err)
+		}
+	}
+}
+
+func TestGarbageHeaders(t *testing.T) {
+	h := http.Header{}
+	GarbageHeaders(h, rand.New(rand.NewSource(1)), 100)
+	if len(h) < 90 {
+		t.Errorf("Expected about 100 headers, got %v", len(h))
+	}
+	long := false
+	for _, vals := range h {
+		long = long || len(vals[0]) > 1024
+	}
+	if !long {
+		t.Error("Expected some oversized values")
+	}
+}
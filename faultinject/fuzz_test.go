@@ -0,0 +1,55 @@
+//go:build go1.18
+// +build go1.18
+
+package faultinject
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// joinErrors are the errors the Tracer.Join() contract allows for a
+// well-typed carrier.
+var joinErrors = map[error]bool{
+	nil:                           true,
+	opentracing.ErrTraceNotFound:  true,
+	opentracing.ErrTraceCorrupted: true,
+}
+
+func FuzzJoinGarbageHeaders(f *testing.F) {
+	f.Add(int64(0), 10)
+	f.Add(int64(1), 100)
+	tracers := []opentracing.Tracer{
+		basictracer.New(recorder.NewInMemoryRecorder()),
+		mocktracer.New(),
+	}
+	f.Fuzz(func(t *testing.T, seed int64, n int) {
+		if n < 0 || n > 1000 {
+			return
+		}
+		rnd := rand.New(rand.NewSource(seed))
+		for i, tracer := range tracers {
+			h := http.Header{}
+			client := tracer.StartSpan("client")
+			tracer.Inject(client, opentracing.TextMap, opentracing.HTTPHeaderTextMapCarrier(h))
+			client.Finish()
+			GarbageHeaders(h, rnd, n)
+			sp, err := tracer.Join("server", opentracing.TextMap, opentracing.HTTPHeaderTextMapCarrier(h))
+			if !joinErrors[err] {
+				t.Errorf("tracer %d: unexpected Join error %v", i, err)
+			}
+			if (sp == nil) != (err != nil) {
+				t.Errorf("tracer %d: Join returned %v, %v", i, sp, err)
+			}
+			if sp != nil {
+				sp.Finish()
+			}
+		}
+	})
+}
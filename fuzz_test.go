@@ -0,0 +1,55 @@
+//go:build go1.18
+// +build go1.18
+
+package opentracing
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func FuzzHTTPHeaderTextMapCarrierRoundTrip(f *testing.F) {
+	f.Add("testprefix-fakeid", "42")
+	f.Add("ot-baggage-user", "a b%c=d&e")
+	f.Add("x", "")
+	f.Fuzz(func(t *testing.T, key, val string) {
+		h := http.Header{}
+		carrier := HTTPHeaderTextMapCarrier(h)
+		carrier.Set(key, val)
+		var found []string
+		if err := carrier.ForeachKey(func(k, v string) error {
+			if !strings.EqualFold(k, key) {
+				t.Errorf("Set(%q) yielded key %q", key, k)
+			}
+			found = append(found, v)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(found) != 1 || found[0] != val {
+			t.Errorf("Set(%q, %q) yielded %q", key, val, found)
+		}
+	})
+}
+
+func FuzzHTTPHeaderTextMapCarrierForeachKey(f *testing.F) {
+	f.Add("testprefix-fakeid", "42")
+	f.Add("x", "%zz")
+	f.Add("x", "%")
+	f.Fuzz(func(t *testing.T, key, raw string) {
+		// Arbitrary (possibly badly escaped) headers must be skipped or
+		// decoded, never cause a panic or an error.
+		h := http.Header{key: []string{raw, raw}}
+		calls := 0
+		if err := HTTPHeaderTextMapCarrier(h).ForeachKey(func(k, v string) error {
+			calls++
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if calls != 0 && calls != 2 {
+			t.Errorf("Unexpected number of entries %v", calls)
+		}
+	})
+}
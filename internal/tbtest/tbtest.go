@@ -0,0 +1,29 @@
+// Package tbtest provides a fake testing.TB, to test the test helpers of
+// this repository.
+package tbtest
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Recorder records the failures reported through it instead of failing a
+// test. Only Helper, Error and Errorf are implemented; the other methods of
+// testing.TB panic.
+type Recorder struct {
+	testing.TB
+	Errors []string
+}
+
+// Helper belongs to the testing.TB interface.
+func (r *Recorder) Helper() {}
+
+// Error belongs to the testing.TB interface.
+func (r *Recorder) Error(args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprint(args...))
+}
+
+// Errorf belongs to the testing.TB interface.
+func (r *Recorder) Errorf(format string, args ...interface{}) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,137 @@
+package mocktracer
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// TB is the subset of testing.TB used by the Assert functions, so that this
+// package does not depend on the testing package. If `tb` also has a
+// Helper() method, as testing.TB does since Go 1.9, they call it.
+type TB interface {
+	Errorf(format string, args ...interface{})
+}
+
+type helper interface {
+	Helper()
+}
+
+// The Assert functions report a readable failure to `tb` and return false
+// if the assertion does not hold, so that callers can stop early:
+//
+//	if !mocktracer.AssertTag(t, sp, "http.status_code", 200) {
+//	    return
+//	}
+
+// AssertTag checks that `sp` has tag `key` set to `expected` (compared with
+// reflect.DeepEqual, so the types must match too).
+func AssertTag(tb TB, sp *MockSpan, key string, expected interface{}) bool {
+	if h, ok := tb.(helper); ok {
+		h.Helper()
+	}
+	actual, ok := sp.Tags[key]
+	if ok && reflect.DeepEqual(actual, expected) {
+		return true
+	}
+	if !ok {
+		tb.Errorf("span %q has no tag %q, want %#v\ntags:\n%s", sp.OperationName, key, expected, formatTags(sp.Tags))
+	} else {
+		tb.Errorf("span %q tag %q:\n got  %#v (%T)\n want %#v (%T)", sp.OperationName, key, actual, actual, expected, expected)
+	}
+	return false
+}
+
+// AssertTags checks that the tags of `sp` are exactly `expected`.
+func AssertTags(tb TB, sp *MockSpan, expected map[string]interface{}) bool {
+	if h, ok := tb.(helper); ok {
+		h.Helper()
+	}
+	if reflect.DeepEqual(sp.Tags, expected) || (len(sp.Tags) == 0 && len(expected) == 0) {
+		return true
+	}
+	tb.Errorf("span %q tags differ (-got +want):\n%s", sp.OperationName, diffTags(sp.Tags, expected))
+	return false
+}
+
+// AssertLogged checks that `sp` logged `event`.
+func AssertLogged(tb TB, sp *MockSpan, event string) bool {
+	if h, ok := tb.(helper); ok {
+		h.Helper()
+	}
+	var events bytes.Buffer
+	for _, ld := range sp.Logs {
+		if ld.Event == event {
+			return true
+		}
+		fmt.Fprintf(&events, "  %q\n", ld.Event)
+	}
+	tb.Errorf("span %q did not log %q\nlogged events:\n%s", sp.OperationName, event, events.String())
+	return false
+}
+
+// AssertAncestor checks that `ancestor` is a (possibly indirect) parent of
+// `descendant`. Intermediate spans must have finished or still be active.
+func AssertAncestor(tb TB, tracer *MockTracer, ancestor, descendant *MockSpan) bool {
+	if h, ok := tb.(helper); ok {
+		h.Helper()
+	}
+	var chain bytes.Buffer
+	fmt.Fprintf(&chain, "  %q (%d)\n", descendant.OperationName, descendant.SpanID)
+	seen := map[*MockSpan]bool{descendant: true}
+	for sp := tracer.Parent(descendant); sp != nil && !seen[sp]; sp = tracer.Parent(sp) {
+		if sp == ancestor {
+			return true
+		}
+		seen[sp] = true
+		fmt.Fprintf(&chain, "  %q (%d)\n", sp.OperationName, sp.SpanID)
+	}
+	tb.Errorf("span %q (%d) is not an ancestor of %q (%d)\nancestry:\n%s",
+		ancestor.OperationName, ancestor.SpanID, descendant.OperationName, descendant.SpanID, chain.String())
+	return false
+}
+
+func formatTags(tags map[string]interface{}) string {
+	var buf bytes.Buffer
+	for _, k := range sortedKeys(tags) {
+		fmt.Fprintf(&buf, "  %s: %#v\n", k, tags[k])
+	}
+	return buf.String()
+}
+
+func diffTags(actual, expected map[string]interface{}) string {
+	keys := sortedKeys(actual)
+	for k := range expected {
+		if _, ok := actual[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, k := range keys {
+		a, inActual := actual[k]
+		e, inExpected := expected[k]
+		switch {
+		case inActual && inExpected && reflect.DeepEqual(a, e):
+			fmt.Fprintf(&buf, "   %s: %#v\n", k, a)
+		default:
+			if inActual {
+				fmt.Fprintf(&buf, " - %s: %#v\n", k, a)
+			}
+			if inExpected {
+				fmt.Fprintf(&buf, " + %s: %#v\n", k, e)
+			}
+		}
+	}
+	return buf.String()
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
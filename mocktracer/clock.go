@@ -0,0 +1,74 @@
+package mocktracer
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the timestamps of a MockTracer.
+type Clock interface {
+	Now() time.Time
+}
+
+// ManualClock is a Clock that only moves when told to, for tests that assert
+// on span timings. It is safe for concurrent use.
+type ManualClock struct {
+	lock sync.Mutex
+	now  time.Time
+}
+
+// NewManualClock returns a ManualClock set to `now`.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+// Now belongs to the Clock interface.
+func (c *ManualClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by `d`.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set sets the clock to `now`.
+func (c *ManualClock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = now
+}
+
+// WithClock makes the MockTracer take the start and finish times of its
+// spans, when not given explicitly, from `clock` instead of time.Now().
+func WithClock(clock Clock) Option {
+	return func(t *MockTracer) {
+		t.clock = clock
+	}
+}
+
+// WithIDGenerator makes the MockTracer take its SpanIDs from `next`
+// instead of the counter shared by all MockTracers.
+func WithIDGenerator(next func() int) Option {
+	return func(t *MockTracer) {
+		t.nextID = next
+	}
+}
+
+// SequentialIDs makes the MockTracer number its spans 1, 2, 3, ...
+// independently of other MockTracers, so that IDs do not depend on the order
+// in which tests run.
+func SequentialIDs() Option {
+	var lock sync.Mutex
+	id := 0
+	return WithIDGenerator(func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		id++
+		return id
+	})
+}
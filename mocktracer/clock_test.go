@@ -0,0 +1,60 @@
+package mocktracer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func TestClockAndIDs(t *testing.T) {
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewManualClock(start)
+	tracer := New(WithClock(clock), SequentialIDs())
+	other := New(SequentialIDs())
+
+	root := tracer.StartSpan("root").(*MockSpan)
+	clock.Advance(time.Second)
+	child := opentracing.StartChildSpan(root, "child").(*MockSpan)
+	other.StartSpan("other").Finish()
+	clock.Advance(time.Second)
+	child.FinishWithOptions(opentracing.FinishOptions{})
+	clock.Set(start.Add(time.Minute))
+	root.Finish()
+
+	if root.SpanID != 1 || child.SpanID != 2 || other.FinishedSpans[0].SpanID != 1 {
+		t.Errorf("Unexpected IDs %v, %v, %v", root.SpanID, child.SpanID, other.FinishedSpans[0].SpanID)
+	}
+	if !root.StartTime.Equal(start) || !child.StartTime.Equal(start.Add(time.Second)) {
+		t.Errorf("Unexpected start times %v, %v", root.StartTime, child.StartTime)
+	}
+	if !child.FinishTime.Equal(start.Add(2*time.Second)) || !root.FinishTime.Equal(start.Add(time.Minute)) {
+		t.Errorf("Unexpected finish times %v, %v", child.FinishTime, root.FinishTime)
+	}
+}
+
+func TestDefaultIDsAreUnique(t *testing.T) {
+	var wg sync.WaitGroup
+	ids := make([][]int, 4)
+	for i := range ids {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tracer := New()
+			for j := 0; j < 100; j++ {
+				ids[i] = append(ids[i], tracer.StartSpan("op").(*MockSpan).SpanID)
+			}
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[int]bool)
+	for _, tracerIDs := range ids {
+		for _, id := range tracerIDs {
+			if seen[id] {
+				t.Fatalf("Duplicate span ID %v", id)
+			}
+			seen[id] = true
+		}
+	}
+}
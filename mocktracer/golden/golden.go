@@ -0,0 +1,217 @@
+// Package golden compares the spans finished by a mocktracer.MockTracer with
+// golden files, so that instrumentation tests can check everything they
+// emit in a single line:
+//
+//	func TestHandler(t *testing.T) {
+//	    tracer := mocktracer.New()
+//	    ... exercise the instrumented code ...
+//	    golden.Snapshot(t, tracer, "handler")
+//	}
+//
+// The snapshot is compared with testdata/handler.golden. Run the tests with
+// the -golden.update flag to (re)write the golden files:
+//
+//	go test -run TestHandler -golden.update
+//
+// The flag is namespaced so that it does not clash with an -update flag
+// defined by the test itself.
+//
+// Span IDs are replaced by sequence numbers and timestamps by offsets from
+// the start of the trace, so snapshots are stable as long as the code under
+// test uses explicit timestamps or the MockTracer has a deterministic clock
+// (see mocktracer.WithClock and mocktracer.SequentialIDs); otherwise use
+// SnapshotWithOptions to leave timings out.
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+var update = flag.Bool("golden.update", false, "rewrite the golden files of mocktracer snapshots")
+
+// TB is the subset of testing.TB used by Snapshot, so that this package does
+// not depend on the testing package. If `tb` also has a Helper() method, as
+// testing.TB does since Go 1.9, Snapshot calls it.
+type TB interface {
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+}
+
+type helper interface {
+	Helper()
+}
+
+// Options configures SnapshotWithOptions.
+type Options struct {
+	// IgnoreTimings leaves span durations and start and log offsets out of
+	// the snapshot.
+	IgnoreTimings bool
+}
+
+// Snapshot compares the FinishedSpans of `tracer` with the golden file
+// testdata/<name>.golden, or rewrites it if the -golden.update flag is set.
+func Snapshot(tb TB, tracer *mocktracer.MockTracer, name string) {
+	if h, ok := tb.(helper); ok {
+		h.Helper()
+	}
+	SnapshotWithOptions(tb, tracer, name, Options{})
+}
+
+// SnapshotWithOptions is Snapshot with Options.
+func SnapshotWithOptions(tb TB, tracer *mocktracer.MockTracer, name string, opts Options) {
+	if h, ok := tb.(helper); ok {
+		h.Helper()
+	}
+	compare(tb, Serialize(tracer.FinishedSpans, opts), filepath.Join("testdata", name+".golden"), *update)
+}
+
+func compare(tb TB, actual, path string, update bool) {
+	if h, ok := tb.(helper); ok {
+		h.Helper()
+	}
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			tb.Fatal(err)
+		}
+		return
+	}
+	expected, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		tb.Errorf("golden file %v does not exist; run the test with -golden.update to create it", path)
+		return
+	} else if err != nil {
+		tb.Fatal(err)
+	}
+	if string(expected) != actual {
+		tb.Errorf("spans differ from golden file %v (-golden +actual); run the test with -golden.update to accept them:\n%s",
+			path, diffLines(string(expected), actual))
+	}
+}
+
+// Serialize returns the text form of `spans` used by Snapshot: the trace
+// trees of mocktracer.BuildForest, with every span numbered in depth-first
+// order.
+func Serialize(spans []*mocktracer.MockSpan, opts Options) string {
+	var buf bytes.Buffer
+	seq := 0
+	for _, root := range mocktracer.BuildForest(spans).Roots {
+		writeNode(&buf, root, root.Span.StartTime, 0, &seq, opts)
+	}
+	return buf.String()
+}
+
+func writeNode(buf *bytes.Buffer, n *mocktracer.TraceNode, origin time.Time, depth int, seq *int, opts Options) {
+	*seq++
+	sp := n.Span
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(buf, "%s#%d %q", indent, *seq, sp.OperationName)
+	if n.Orphan {
+		buf.WriteString(" [orphan]")
+	}
+	if n.Cycle {
+		buf.WriteString(" [cycle]")
+	}
+	buf.WriteByte('\n')
+	if !opts.IgnoreTimings {
+		fmt.Fprintf(buf, "%s  start: +%v duration: %v\n", indent, sp.StartTime.Sub(origin), sp.FinishTime.Sub(sp.StartTime))
+	}
+	writeMap(buf, indent, "tags", sp.Tags)
+	baggage := make(map[string]interface{}, len(sp.Baggage))
+	for k, v := range sp.Baggage {
+		baggage[k] = v
+	}
+	writeMap(buf, indent, "baggage", baggage)
+	if len(sp.Logs) > 0 {
+		fmt.Fprintf(buf, "%s  logs:\n", indent)
+		for _, ld := range sp.Logs {
+			fmt.Fprintf(buf, "%s    ", indent)
+			if !opts.IgnoreTimings && !ld.Timestamp.IsZero() {
+				fmt.Fprintf(buf, "+%v ", ld.Timestamp.Sub(origin))
+			}
+			fmt.Fprintf(buf, "%q", ld.Event)
+			if ld.Payload != nil {
+				fmt.Fprintf(buf, " %s", formatValue(ld.Payload))
+			}
+			buf.WriteByte('\n')
+		}
+	}
+	for _, c := range n.Children {
+		writeNode(buf, c, origin, depth+1, seq, opts)
+	}
+}
+
+func writeMap(buf *bytes.Buffer, indent, title string, m map[string]interface{}) {
+	if len(m) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fmt.Fprintf(buf, "%s  %s:\n", indent, title)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "%s    %s: %s\n", indent, k, formatValue(m[k]))
+	}
+}
+
+// formatValue formats strings quoted and other values with their type,
+// e.g. uint16(200), so that type changes show up in snapshots.
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%T(%+v)", v, v)
+}
+
+// diffLines returns a line-based diff of `a` and `b`, computed from their
+// longest common subsequence.
+func diffLines(a, b string) string {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+	// lcs[i][j] is the length of the LCS of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var buf bytes.Buffer
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			fmt.Fprintf(&buf, "  %s\n", x[i])
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			fmt.Fprintf(&buf, "- %s\n", x[i])
+			i++
+		default:
+			fmt.Fprintf(&buf, "+ %s\n", y[j])
+			j++
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,80 @@
+package golden
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/internal/tbtest"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func newTracer() *mocktracer.MockTracer {
+	tracer := mocktracer.New()
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	root := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: "GET /feed",
+		StartTime:     start,
+		Tags:          opentracing.Tags{"http.status_code": uint16(200)},
+	})
+	root.SetBaggageItem("user", "alice")
+	child := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: "query",
+		Parent:        root,
+		StartTime:     start.Add(time.Millisecond),
+	})
+	child.Log(opentracing.LogData{Event: "rows", Payload: 3, Timestamp: start.Add(3 * time.Millisecond)})
+	child.LogEvent("done")
+	child.FinishWithOptions(opentracing.FinishOptions{FinishTime: start.Add(5 * time.Millisecond)})
+	root.FinishWithOptions(opentracing.FinishOptions{FinishTime: start.Add(10 * time.Millisecond)})
+	return tracer
+}
+
+func TestSnapshot(t *testing.T) {
+	Snapshot(t, newTracer(), "feed")
+}
+
+func TestIgnoreTimings(t *testing.T) {
+	expected := `#1 "GET /feed"
+  tags:
+    http.status_code: uint16(200)
+  baggage:
+    user: "alice"
+  #2 "query"
+    baggage:
+      user: "alice"
+    logs:
+      "rows" int(3)
+      "done"
+`
+	if got := Serialize(newTracer().FinishedSpans, Options{IgnoreTimings: true}); got != expected {
+		t.Errorf("Unexpected serialization:\n%s", got)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "testdata", "x.golden")
+
+	tb := &tbtest.Recorder{TB: t}
+	compare(tb, "a\nb\nc\n", path, false)
+	if len(tb.Errors) != 1 || !strings.Contains(tb.Errors[0], "does not exist") {
+		t.Errorf("Unexpected failures %q", tb.Errors)
+	}
+
+	tb = &tbtest.Recorder{TB: t}
+	compare(tb, "a\nb\nc\n", path, true)
+	compare(tb, "a\nb\nc\n", path, false)
+	compare(tb, "a\nB\nc\n", path, false)
+	if len(tb.Errors) != 1 || !strings.HasSuffix(tb.Errors[0], ":\n  a\n- b\n+ B\n  c\n  \n") {
+		t.Errorf("Unexpected failures %q", tb.Errors)
+	}
+}
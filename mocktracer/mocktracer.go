@@ -3,16 +3,39 @@ package mocktracer
 import (
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+	"github.com/opentracing/opentracing-go/tracker"
+	"github.com/opentracing/opentracing-go/validate"
 )
 
 // New returns a MockTracer opentracing.Tracer implementation that's intended
 // to facilitate tests of OpenTracing instrumentation.
-func New() *MockTracer {
-	return &MockTracer{
+func New(opts ...Option) *MockTracer {
+	t := &MockTracer{
 		FinishedSpans: []*MockSpan{},
+		active:        tracker.New(),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Option configures a MockTracer created by New.
+type Option func(t *MockTracer)
+
+// Strict makes the MockTracer check that its spans are used according to
+// the opentracing.Span contract: see package validate. Violations are
+// available through MockTracer.Violations(). In strict mode, a MockSpan that
+// is finished twice is only added to FinishedSpans once.
+func Strict() Option {
+	return func(t *MockTracer) {
+		t.validator = validate.New(validate.Options{})
 	}
 }
 
@@ -21,11 +44,53 @@ func New() *MockTracer {
 // to verify tracing behavior.
 type MockTracer struct {
 	FinishedSpans []*MockSpan
+
+	// BaggagePolicy, if non-nil, is applied by MockSpan.SetBaggageItem() and
+	// to the baggage decoded by Join(). Items rejected by SetBaggageItem()
+	// are also logged to the span as a BaggageDroppedEvent with an
+	// opentracing.BaggageDrop payload.
+	BaggagePolicy *opentracing.BaggagePolicy
+
+	// InjectErr and JoinErr, if non-nil, are returned by every Inject() and
+	// Join() call respectively, to exercise error handling paths. They are
+	// not synchronized: set or clear them only while no other goroutine
+	// calls Inject() or Join().
+	InjectErr error
+	JoinErr   error
+
+	active    *tracker.Tracker
+	validator *validate.Validator
+	clock     Clock
+	nextID    func() int
+
+	lock        sync.Mutex // protects FinishedSpans and the fields below
+	subscribers []*subscription
 }
 
+func (t *MockTracer) now() time.Time {
+	if t.clock == nil {
+		return time.Now()
+	}
+	return t.clock.Now()
+}
+
+func (t *MockTracer) newSpanID() int {
+	if t.nextID == nil {
+		return nextMockID()
+	}
+	return t.nextID()
+}
+
+// BaggageDroppedEvent is the LogData.Event recorded by a MockSpan when its
+// tracer's BaggagePolicy rejects a baggage item.
+const BaggageDroppedEvent = "baggage.dropped"
+
 // MockSpan is an opentracing.Span implementation that exports its internal
 // state for testing purposes.
 type MockSpan struct {
+	// TraceID is shared by all the spans of a trace; it is the SpanID of
+	// the trace's root span unless the trace was joined from a carrier.
+	TraceID  int
 	SpanID   int
 	ParentID int
 
@@ -36,16 +101,47 @@ type MockSpan struct {
 	Baggage       map[string]string
 	Logs          []opentracing.LogData
 
-	tracer *MockTracer
+	tracer   *MockTracer
+	finished int32 // accessed atomically, since IsRecording may race Finish
+	joined   bool
 }
 
 // Reset clears the exported MockTracer.FinishedSpans field. Note that any
 // extant MockSpans will still append to FinishedSpans when they Finish(), even
 // after a call to Reset().
 func (t *MockTracer) Reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
 	t.FinishedSpans = []*MockSpan{}
 }
 
+// Tracker returns the tracker.Tracker that records the MockTracer's started
+// but unfinished spans. Tests can call Tracker().Verify(t) to check that
+// every span was finished.
+func (t *MockTracer) Tracker() *tracker.Tracker {
+	return t.active
+}
+
+// Violations returns the span contract violations detected so far, or nil
+// if the MockTracer was not created with the Strict() option.
+func (t *MockTracer) Violations() []validate.Violation {
+	if t.validator == nil {
+		return nil
+	}
+	return t.validator.Violations()
+}
+
+// ActiveSpans returns the MockSpans that have been started but not finished,
+// oldest first.
+func (t *MockTracer) ActiveSpans() []*MockSpan {
+	active := t.active.Active()
+	rval := make([]*MockSpan, len(active))
+	for i, sp := range active {
+		rval[i] = sp.Span.(*MockSpan)
+	}
+	return rval
+}
+
 // StartSpan belongs to the Tracer interface.
 func (t *MockTracer) StartSpan(operationName string) opentracing.Span {
 	return newMockSpan(t, opentracing.StartSpanOptions{
@@ -63,11 +159,21 @@ const mockTextMapBaggagePrefix = "mockpfx-baggage-"
 
 // Inject belongs to the Tracer interface.
 func (t *MockTracer) Inject(sp opentracing.Span, format interface{}, carrier interface{}) error {
-	span := sp.(*MockSpan)
+	if t.InjectErr != nil {
+		return t.InjectErr
+	}
+	span, ok := sp.(*MockSpan)
+	if !ok {
+		return opentracing.ErrInvalidSpan
+	}
 	switch format {
 	case opentracing.TextMap:
-		writer := carrier.(opentracing.TextMapWriter)
+		writer, ok := carrier.(opentracing.TextMapWriter)
+		if !ok {
+			return opentracing.ErrInvalidCarrier
+		}
 		// Ids:
+		writer.Set(mockTextMapIdsPrefix+"traceid", strconv.Itoa(span.TraceID))
 		writer.Set(mockTextMapIdsPrefix+"spanid", strconv.Itoa(span.SpanID))
 		// Baggage:
 		for baggageKey, baggageVal := range span.Baggage {
@@ -78,95 +184,212 @@ func (t *MockTracer) Inject(sp opentracing.Span, format interface{}, carrier int
 	return opentracing.ErrUnsupportedFormat
 }
 
-// Join belongs to the Tracer interface.
+// Join belongs to the Tracer interface. It follows the error contract of
+// opentracing.Tracer.Join(): ErrTraceNotFound if `carrier` holds no mock
+// IDs, ErrTraceCorrupted if they are malformed or incomplete,
+// ErrInvalidCarrier if `carrier` does not match `format`, and
+// ErrUnsupportedFormat for formats other than TextMap.
 func (t *MockTracer) Join(operationName string, format interface{}, carrier interface{}) (opentracing.Span, error) {
+	if t.JoinErr != nil {
+		return nil, t.JoinErr
+	}
 	switch format {
 	case opentracing.TextMap:
-		rval := newMockSpan(t, opentracing.StartSpanOptions{
-			OperationName: operationName,
-		})
-		err := carrier.(opentracing.TextMapReader).ForeachKey(func(key, val string) error {
+		reader, ok := carrier.(opentracing.TextMapReader)
+		if !ok {
+			return nil, opentracing.ErrInvalidCarrier
+		}
+		var traceID, parentID int
+		baggage := map[string]string{}
+		err := reader.ForeachKey(func(key, val string) error {
 			lowerKey := strings.ToLower(key)
 			switch {
-			case lowerKey == mockTextMapIdsPrefix+"spanid":
+			case lowerKey == mockTextMapIdsPrefix+"traceid":
 				// Ids:
 				i, err := strconv.Atoi(val)
-				if err != nil {
-					return err
+				if err != nil || i <= 0 {
+					return opentracing.ErrTraceCorrupted
 				}
-				rval.ParentID = i
+				traceID = i
+			case lowerKey == mockTextMapIdsPrefix+"spanid":
+				i, err := strconv.Atoi(val)
+				if err != nil || i <= 0 {
+					return opentracing.ErrTraceCorrupted
+				}
+				parentID = i
 			case strings.HasPrefix(lowerKey, mockTextMapBaggagePrefix):
 				// Baggage:
-				rval.Baggage[lowerKey[len(mockTextMapBaggagePrefix):]] = val
+				baggage[lowerKey[len(mockTextMapBaggagePrefix):]] = val
 			}
 			return nil
 		})
-		return rval, err
+		switch {
+		case err != nil:
+			return nil, err
+		case traceID == 0 && parentID == 0:
+			return nil, opentracing.ErrTraceNotFound
+		case traceID == 0 || parentID == 0:
+			return nil, opentracing.ErrTraceCorrupted
+		}
+		rval := t.newSpan(opentracing.StartSpanOptions{
+			OperationName: operationName,
+		}, traceID, parentID, t.BaggagePolicy.Filter(baggage))
+		rval.joined = true
+		return rval, nil
 	}
-	return nil, opentracing.ErrTraceNotFound
+	return nil, opentracing.ErrUnsupportedFormat
 }
 
-var mockIDSource = 1
+// mockIDSource is shared by the MockTracers that do not have their own ID
+// generator, which may be used concurrently.
+var mockIDSource = int64(1)
 
 func nextMockID() int {
-	mockIDSource++
-	return mockIDSource
+	return int(atomic.AddInt64(&mockIDSource, 1))
 }
 
 func newMockSpan(t *MockTracer, opts opentracing.StartSpanOptions) *MockSpan {
+	var traceID, parentID int
+	baggage := map[string]string{}
+	if opts.Parent != nil {
+		parent := opts.Parent.(*MockSpan)
+		traceID, parentID = parent.TraceID, parent.SpanID
+		for k, v := range parent.Baggage {
+			baggage[k] = v
+		}
+	}
+	return t.newSpan(opts, traceID, parentID, baggage)
+}
+
+// newSpan starts a span of trace `traceID` (or of a new trace if zero) and
+// takes ownership of `baggage`.
+func (t *MockTracer) newSpan(opts opentracing.StartSpanOptions, traceID, parentID int, baggage map[string]string) *MockSpan {
 	tags := opts.Tags
 	if tags == nil {
 		tags = map[string]interface{}{}
 	}
-	parentID := int(0)
-	if opts.Parent != nil {
-		parentID = opts.Parent.(*MockSpan).SpanID
-	}
 	startTime := opts.StartTime
 	if startTime.IsZero() {
-		startTime = time.Now()
+		startTime = t.now()
 	}
-	return &MockSpan{
-		SpanID:   nextMockID(),
+	spanID := t.newSpanID()
+	if traceID == 0 {
+		traceID = spanID
+	}
+	sp := &MockSpan{
+		TraceID:  traceID,
+		SpanID:   spanID,
 		ParentID: parentID,
 
 		OperationName: opts.OperationName,
 		StartTime:     startTime,
 		Tags:          tags,
-		Baggage:       map[string]string{},
+		Baggage:       baggage,
 		Logs:          []opentracing.LogData{},
 
 		tracer: t,
 	}
+	t.active.Track(sp, opts.OperationName)
+	return sp
+}
+
+// RawSpan returns a snapshot of the span in the form consumed by span
+// recorders and exporters. The MockSpan must have finished.
+func (s *MockSpan) RawSpan() recorder.RawSpan {
+	tags := make(opentracing.Tags, len(s.Tags))
+	for k, v := range s.Tags {
+		tags[k] = v
+	}
+	baggage := make(map[string]string, len(s.Baggage))
+	for k, v := range s.Baggage {
+		baggage[k] = v
+	}
+	return recorder.RawSpan{
+		Context: recorder.SpanContext{
+			TraceID: recorder.TraceID{Low: uint64(s.TraceID)},
+			SpanID:  uint64(s.SpanID),
+			Sampled: true,
+			Baggage: baggage,
+		},
+		ParentSpanID: uint64(s.ParentID),
+		LocalRoot:    s.ParentID == 0 || s.joined,
+		Operation:    s.OperationName,
+		Start:        s.StartTime,
+		Duration:     s.FinishTime.Sub(s.StartTime),
+		Tags:         tags,
+		Logs:         append([]opentracing.LogData(nil), s.Logs...),
+	}
+}
+
+// checkActive reports a use after finish in strict mode.
+func (s *MockSpan) checkActive(method string) {
+	if atomic.LoadInt32(&s.finished) != 0 && s.tracer.validator != nil {
+		s.tracer.validator.Report(validate.UseAfterFinish, s.OperationName, method, "")
+	}
+}
+
+// finish returns false if the span must not be finished again.
+func (s *MockSpan) finish(method string) bool {
+	wasFinished := !atomic.CompareAndSwapInt32(&s.finished, 0, 1)
+	if !wasFinished || s.tracer.validator == nil {
+		return true
+	}
+	s.tracer.validator.Report(validate.DoubleFinish, s.OperationName, method, "")
+	return false
 }
 
 // SetTag belongs to the Span interface
 func (s *MockSpan) SetTag(key string, value interface{}) opentracing.Span {
+	s.checkActive("SetTag")
 	s.Tags[key] = value
 	return s
 }
 
 // Finish belongs to the Span interface
 func (s *MockSpan) Finish() {
-	s.FinishTime = time.Now()
-	s.tracer.FinishedSpans = append(s.tracer.FinishedSpans, s)
+	if !s.finish("Finish") {
+		return
+	}
+	s.FinishTime = s.tracer.now()
+	s.tracer.active.Untrack(s)
+	s.tracer.recordFinished(s)
 }
 
 // FinishWithOptions belongs to the Span interface
 func (s *MockSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	if !s.finish("FinishWithOptions") {
+		return
+	}
+	if opts.FinishTime.IsZero() {
+		opts.FinishTime = s.tracer.now()
+	}
+	if s.tracer.validator != nil {
+		s.tracer.validator.CheckFinish(s.OperationName, s.StartTime, opts)
+	}
 	s.FinishTime = opts.FinishTime
 	s.Logs = append(s.Logs, opts.BulkLogData...)
-	s.tracer.FinishedSpans = append(s.tracer.FinishedSpans, s)
+	s.tracer.active.Untrack(s)
+	s.tracer.recordFinished(s)
 }
 
 // SetBaggageItem belongs to the Span interface
 func (s *MockSpan) SetBaggageItem(key, val string) opentracing.Span {
+	s.checkActive("SetBaggageItem")
+	if err := s.tracer.BaggagePolicy.Check(s.Baggage, key, val); err != nil {
+		drop := opentracing.BaggageDrop{Key: key, Value: val, Err: err}
+		if onDrop := s.tracer.BaggagePolicy.OnDrop; onDrop != nil {
+			onDrop(drop)
+		}
+		s.log(opentracing.LogData{Event: BaggageDroppedEvent, Payload: drop})
+		return s
+	}
 	s.Baggage[key] = val
 	return s
 }
 
 // BaggageItem belongs to the Span interface
 func (s *MockSpan) BaggageItem(key string) string {
+	s.checkActive("BaggageItem")
 	return s.Baggage[key]
 }
 
@@ -187,12 +410,19 @@ func (s *MockSpan) LogEventWithPayload(event string, payload interface{}) {
 
 // Log belongs to the Span interface
 func (s *MockSpan) Log(data opentracing.LogData) {
+	s.checkActive("Log")
+	s.log(data)
+}
+
+func (s *MockSpan) log(data opentracing.LogData) {
 	s.Logs = append(s.Logs, data)
 }
 
 // SetOperationName belongs to the Span interface
 func (s *MockSpan) SetOperationName(operationName string) opentracing.Span {
+	s.checkActive("SetOperationName")
 	s.OperationName = operationName
+	s.tracer.active.SetOperationName(s, operationName)
 	return s
 }
 
@@ -200,3 +430,9 @@ func (s *MockSpan) SetOperationName(operationName string) opentracing.Span {
 func (s *MockSpan) Tracer() opentracing.Tracer {
 	return s.tracer
 }
+
+// IsRecording belongs to the opentracing.RecordingSpan interface. A MockSpan
+// records everything until it finishes.
+func (s *MockSpan) IsRecording() bool {
+	return atomic.LoadInt32(&s.finished) == 0
+}
@@ -0,0 +1,117 @@
+package mocktracer
+
+import (
+	"net/http"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func TestTraceIDPropagation(t *testing.T) {
+	tracer := New()
+	root := tracer.StartSpan("root").(*MockSpan)
+	root.SetBaggageItem("user", "alice")
+	child := opentracing.StartChildSpan(root, "child").(*MockSpan)
+	if child.TraceID != root.TraceID || root.TraceID != root.SpanID {
+		t.Errorf("Unexpected trace IDs: root %v/%v, child %v", root.TraceID, root.SpanID, child.TraceID)
+	}
+	if child.BaggageItem("user") != "alice" {
+		t.Errorf("Child did not inherit baggage: %v", child.Baggage)
+	}
+	child.SetBaggageItem("user", "bob")
+	if root.BaggageItem("user") != "alice" {
+		t.Error("Child baggage leaked into the parent")
+	}
+
+	carrier := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+	if err := tracer.Inject(child, opentracing.TextMap, carrier); err != nil {
+		t.Fatal(err)
+	}
+	joined, err := New().Join("server", opentracing.TextMap, carrier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := joined.(*MockSpan)
+	if server.TraceID != root.TraceID || server.ParentID != child.SpanID || server.BaggageItem("user") != "bob" {
+		t.Errorf("Unexpected joined span %+v", server)
+	}
+	server.Finish()
+	if raw := server.RawSpan(); raw.Context.TraceID.Low != uint64(root.TraceID) || !raw.LocalRoot {
+		t.Errorf("Unexpected RawSpan %+v", raw)
+	}
+	child.Finish()
+	if opentracing.IsRecording(child) || !opentracing.IsRecording(root) {
+		t.Error("Expected only unfinished spans to be recording")
+	}
+	root.Finish()
+}
+
+func TestJoinErrors(t *testing.T) {
+	tracer := New()
+	header := func(kv ...string) opentracing.HTTPHeaderTextMapCarrier {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return opentracing.HTTPHeaderTextMapCarrier(h)
+	}
+	for _, test := range []struct {
+		format  interface{}
+		carrier interface{}
+		err     error
+	}{
+		{opentracing.TextMap, header(), opentracing.ErrTraceNotFound},
+		{opentracing.TextMap, header("mockpfx-baggage-user", "alice"), opentracing.ErrTraceNotFound},
+		{opentracing.TextMap, header("mockpfx-ids-traceid", "1", "mockpfx-ids-spanid", "x"), opentracing.ErrTraceCorrupted},
+		{opentracing.TextMap, header("mockpfx-ids-traceid", "1"), opentracing.ErrTraceCorrupted},
+		{opentracing.TextMap, "not a carrier", opentracing.ErrInvalidCarrier},
+		{opentracing.Binary, header(), opentracing.ErrUnsupportedFormat},
+		{"custom", header(), opentracing.ErrUnsupportedFormat},
+	} {
+		if sp, err := tracer.Join("x", test.format, test.carrier); sp != nil || err != test.err {
+			t.Errorf("Join(%v, %v) = %v, %v; expected %v", test.format, test.carrier, sp, err, test.err)
+		}
+	}
+	if len(tracer.ActiveSpans()) != 0 {
+		t.Error("Failed Join() calls started spans")
+	}
+
+	sp := tracer.StartSpan("x")
+	if err := tracer.Inject(sp, opentracing.TextMap, "not a carrier"); err != opentracing.ErrInvalidCarrier {
+		t.Errorf("Expected ErrInvalidCarrier, got %v", err)
+	}
+	if err := tracer.Inject(opentracing.NoopTracer{}.StartSpan("x"), opentracing.TextMap, header()); err != opentracing.ErrInvalidSpan {
+		t.Errorf("Expected ErrInvalidSpan, got %v", err)
+	}
+
+	tracer.InjectErr, tracer.JoinErr = opentracing.ErrInvalidCarrier, opentracing.ErrTraceCorrupted
+	carrier := header()
+	if err := tracer.Inject(sp, opentracing.TextMap, carrier); err != opentracing.ErrInvalidCarrier || len(carrier) != 0 {
+		t.Errorf("Expected the injected InjectErr, got %v", err)
+	}
+	tracer.InjectErr = nil
+	tracer.Inject(sp, opentracing.TextMap, carrier)
+	if _, err := tracer.Join("x", opentracing.TextMap, carrier); err != opentracing.ErrTraceCorrupted {
+		t.Errorf("Expected the injected JoinErr, got %v", err)
+	}
+	tracer.JoinErr = nil
+	joined, err := tracer.Join("x", opentracing.TextMap, carrier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined.Finish()
+	sp.Finish()
+}
+
+func TestIsRecordingDuringFinish(t *testing.T) {
+	tracer := New()
+	sp := tracer.StartSpan("async")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for sp.(*MockSpan).IsRecording() {
+		}
+	}()
+	sp.Finish()
+	<-done
+}
@@ -0,0 +1,97 @@
+package mocktracer
+
+import (
+	"reflect"
+)
+
+// SpanPredicate selects MockSpans in FindSpans and FindSpan.
+type SpanPredicate func(sp *MockSpan) bool
+
+// WithOperationName selects the spans named `operationName`.
+func WithOperationName(operationName string) SpanPredicate {
+	return func(sp *MockSpan) bool {
+		return sp.OperationName == operationName
+	}
+}
+
+// WithTag selects the spans whose tag `key` is set to `value`, compared with
+// reflect.DeepEqual. A nil `value` selects the spans that have tag `key`
+// regardless of its value.
+func WithTag(key string, value interface{}) SpanPredicate {
+	return func(sp *MockSpan) bool {
+		v, ok := sp.Tags[key]
+		return ok && (value == nil || reflect.DeepEqual(v, value))
+	}
+}
+
+// WithParent selects the children of `parent`.
+func WithParent(parent *MockSpan) SpanPredicate {
+	return func(sp *MockSpan) bool {
+		return sp.ParentID == parent.SpanID
+	}
+}
+
+// finishedSpans returns a copy of FinishedSpans, so that the query functions
+// can be used while other goroutines finish spans.
+func (t *MockTracer) finishedSpans() []*MockSpan {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return append([]*MockSpan(nil), t.FinishedSpans...)
+}
+
+// FindSpans returns the FinishedSpans that satisfy all `predicates`, in
+// finish order.
+func (t *MockTracer) FindSpans(predicates ...SpanPredicate) []*MockSpan {
+	var rval []*MockSpan
+	for _, sp := range t.finishedSpans() {
+		if matches(sp, predicates) {
+			rval = append(rval, sp)
+		}
+	}
+	return rval
+}
+
+// FindSpan returns the first of FindSpans(predicates...), or nil.
+func (t *MockTracer) FindSpan(predicates ...SpanPredicate) *MockSpan {
+	for _, sp := range t.finishedSpans() {
+		if matches(sp, predicates) {
+			return sp
+		}
+	}
+	return nil
+}
+
+// SpansByOperation is shorthand for FindSpans(WithOperationName(name)).
+func (t *MockTracer) SpansByOperation(operationName string) []*MockSpan {
+	return t.FindSpans(WithOperationName(operationName))
+}
+
+// Children returns the finished children of `parent`, in finish order.
+func (t *MockTracer) Children(parent *MockSpan) []*MockSpan {
+	return t.FindSpans(WithParent(parent))
+}
+
+// Parent returns the parent of `sp` if it is finished or active, or nil.
+func (t *MockTracer) Parent(sp *MockSpan) *MockSpan {
+	if sp.ParentID == 0 {
+		return nil
+	}
+	if parent := t.FindSpan(func(p *MockSpan) bool { return p.SpanID == sp.ParentID }); parent != nil {
+		return parent
+	}
+	for _, p := range t.ActiveSpans() {
+		if p.SpanID == sp.ParentID {
+			return p
+		}
+	}
+	return nil
+}
+
+func matches(sp *MockSpan, predicates []SpanPredicate) bool {
+	for _, p := range predicates {
+		if !p(sp) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package mocktracer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/internal/tbtest"
+)
+
+func newTrace() (*MockTracer, *MockSpan, *MockSpan, *MockSpan) {
+	tracer := New()
+	root := tracer.StartSpan("root").(*MockSpan)
+	child := opentracing.StartChildSpan(root, "child").SetTag("db", "users").(*MockSpan)
+	grandchild := opentracing.StartChildSpan(child, "query").SetTag("rows", 3).(*MockSpan)
+	grandchild.LogEvent("retry")
+	grandchild.Finish()
+	child.Finish()
+	return tracer, root, child, grandchild
+}
+
+func TestQueries(t *testing.T) {
+	tracer, root, child, grandchild := newTrace()
+	if spans := tracer.SpansByOperation("query"); len(spans) != 1 || spans[0] != grandchild {
+		t.Errorf("Unexpected SpansByOperation result %v", spans)
+	}
+	if sp := tracer.FindSpan(WithTag("db", nil)); sp != child {
+		t.Errorf("Unexpected FindSpan result %v", sp)
+	}
+	if spans := tracer.FindSpans(WithTag("rows", 3), WithOperationName("child")); len(spans) != 0 {
+		t.Errorf("Unexpected FindSpans result %v", spans)
+	}
+	if children := tracer.Children(child); len(children) != 1 || children[0] != grandchild {
+		t.Errorf("Unexpected Children result %v", children)
+	}
+	if tracer.Parent(child) != root || tracer.Parent(root) != nil {
+		t.Error("Unexpected Parent result")
+	}
+	root.Finish()
+}
+
+func TestQueriesWhileFinishing(t *testing.T) {
+	tracer := New()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			tracer.StartSpan("async").Finish()
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if n := len(tracer.SpansByOperation("async")); n != 100 {
+				t.Errorf("Found %v spans, expected 100", n)
+			}
+			return
+		default:
+			tracer.FindSpan(WithOperationName("async"))
+		}
+	}
+}
+
+func TestAssertions(t *testing.T) {
+	tracer, root, child, grandchild := newTrace()
+	if !AssertTag(t, grandchild, "rows", 3) || !AssertLogged(t, grandchild, "retry") ||
+		!AssertAncestor(t, tracer, root, grandchild) || !AssertTags(t, child, map[string]interface{}{"db": "users"}) {
+		return
+	}
+
+	tb := &tbtest.Recorder{}
+	AssertTag(tb, grandchild, "rows", int64(3))
+	AssertTag(tb, grandchild, "missing", "x")
+	AssertLogged(tb, grandchild, "commit")
+	AssertAncestor(tb, tracer, grandchild, root)
+	AssertTags(tb, child, map[string]interface{}{"db": "orders", "shard": 1})
+	expected := []string{
+		"span \"query\" tag \"rows\":\n got  3 (int)\n want 3 (int64)",
+		"span \"query\" has no tag \"missing\", want \"x\"\ntags:\n  rows: 3\n",
+		"span \"query\" did not log \"commit\"\nlogged events:\n  \"retry\"\n",
+		fmt.Sprintf("span \"query\" (%d) is not an ancestor of \"root\" (%d)\nancestry:\n  \"root\" (%d)\n",
+			grandchild.SpanID, root.SpanID, root.SpanID),
+		"span \"child\" tags differ (-got +want):\n - db: \"users\"\n + db: \"orders\"\n + shard: 1\n",
+	}
+	if len(tb.Errors) != len(expected) {
+		t.Fatalf("Unexpected failures:\n%s", strings.Join(tb.Errors, "\n---\n"))
+	}
+	for i := range expected {
+		if tb.Errors[i] != expected[i] {
+			t.Errorf("Failure %d:\n%s\nexpected:\n%s", i, tb.Errors[i], expected[i])
+		}
+	}
+	root.Finish()
+}
@@ -0,0 +1,89 @@
+package mocktracer
+
+import (
+	"golang.org/x/net/context"
+)
+
+type subscription struct {
+	fn func(sp *MockSpan)
+}
+
+// recordFinished appends `sp` to FinishedSpans and notifies the subscribers.
+func (t *MockTracer) recordFinished(sp *MockSpan) {
+	t.lock.Lock()
+	t.FinishedSpans = append(t.FinishedSpans, sp)
+	// t.subscribers is copied on write, so it can be used unlocked.
+	subscribers := t.subscribers
+	t.lock.Unlock()
+
+	for _, s := range subscribers {
+		s.fn(sp)
+	}
+}
+
+// Subscribe registers `fn` to be called with every MockSpan that finishes
+// from now on, in subscription order. `fn` is called synchronously by
+// Finish() and FinishWithOptions(), from the goroutine that finished the
+// span, so it should not block. The returned function cancels the
+// subscription.
+func (t *MockTracer) Subscribe(fn func(sp *MockSpan)) (unsubscribe func()) {
+	s := &subscription{fn: fn}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	subscribers := make([]*subscription, len(t.subscribers), len(t.subscribers)+1)
+	copy(subscribers, t.subscribers)
+	t.subscribers = append(subscribers, s)
+	return func() {
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		subscribers := make([]*subscription, 0, len(t.subscribers))
+		for _, other := range t.subscribers {
+			if other != s {
+				subscribers = append(subscribers, other)
+			}
+		}
+		t.subscribers = subscribers
+	}
+}
+
+// WaitForSpans blocks until at least `n` of the FinishedSpans satisfy
+// `predicate` (a nil predicate matches every span), counting the spans that
+// finished before the call too. It returns all the matching spans, in finish
+// order, or those matched so far and ctx.Err() if `ctx` is done first.
+//
+// Unlike reading FinishedSpans directly, WaitForSpans is safe to call while
+// other goroutines finish spans:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+//	defer cancel()
+//	spans, err := tracer.WaitForSpans(ctx, mocktracer.WithOperationName("publish"), 1)
+func (t *MockTracer) WaitForSpans(ctx context.Context, predicate SpanPredicate, n int) ([]*MockSpan, error) {
+	notify := make(chan struct{}, 1)
+	unsubscribe := t.Subscribe(func(*MockSpan) {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	})
+	defer unsubscribe()
+
+	for {
+		t.lock.Lock()
+		var matched []*MockSpan
+		for _, sp := range t.FinishedSpans {
+			if predicate == nil || predicate(sp) {
+				matched = append(matched, sp)
+			}
+		}
+		t.lock.Unlock()
+		if len(matched) >= n {
+			return matched, nil
+		}
+
+		select {
+		case <-notify:
+		case <-ctx.Done():
+			return matched, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package mocktracer
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+func TestSubscribe(t *testing.T) {
+	tracer := New()
+	var first, second []string
+	unsubscribe := tracer.Subscribe(func(sp *MockSpan) { first = append(first, sp.OperationName) })
+	tracer.Subscribe(func(sp *MockSpan) { second = append(second, sp.OperationName) })
+
+	tracer.StartSpan("a").Finish()
+	unsubscribe()
+	tracer.StartSpan("b").Finish()
+
+	if len(first) != 1 || first[0] != "a" || len(second) != 2 || second[1] != "b" {
+		t.Errorf("Unexpected notifications %v, %v", first, second)
+	}
+	unsubscribe()
+	if len(tracer.subscribers) != 1 {
+		t.Errorf("Expected one subscription left, got %v", len(tracer.subscribers))
+	}
+}
+
+func TestWaitForSpans(t *testing.T) {
+	tracer := New()
+	tracer.StartSpan("publish").Finish()
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(time.Millisecond)
+			tracer.StartSpan("publish").Finish()
+			tracer.StartSpan("other").Finish()
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	spans, err := tracer.WaitForSpans(ctx, WithOperationName("publish"), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spans) < 4 {
+		t.Errorf("Unexpected spans %v", spans)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	spans, err = tracer.WaitForSpans(ctx, WithOperationName("never"), 1)
+	if err != context.DeadlineExceeded || len(spans) != 0 {
+		t.Errorf("Expected a timeout, got %v, %v", spans, err)
+	}
+}
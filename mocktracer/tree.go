@@ -0,0 +1,188 @@
+package mocktracer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TraceNode is a MockSpan within a Forest.
+type TraceNode struct {
+	Span *MockSpan
+
+	// Children are ordered by start time.
+	Children []*TraceNode
+
+	// Orphan is true if the span has a ParentID but the parent is not part
+	// of the Forest.
+	Orphan bool
+
+	// Cycle is true if the span is part of a parent cycle, which was broken
+	// by making the span a root.
+	Cycle bool
+}
+
+// Forest is the set of trace trees assembled from a set of MockSpans.
+type Forest struct {
+	// Roots holds the root of every tree, ordered by start time. Orphans
+	// and the spans where parent cycles were broken are roots too.
+	Roots []*TraceNode
+
+	// Orphans lists the nodes whose parent is missing.
+	Orphans []*TraceNode
+
+	// Cycles lists the spans of every parent cycle, starting with the span
+	// that was made a root.
+	Cycles [][]*MockSpan
+}
+
+// Forest assembles the tracer's FinishedSpans into trace trees.
+func (t *MockTracer) Forest() *Forest {
+	return BuildForest(t.finishedSpans())
+}
+
+// BuildForest assembles `spans` into trace trees, using their SpanID and
+// ParentID fields. Spans sharing a SpanID are only included once.
+func BuildForest(spans []*MockSpan) *Forest {
+	nodes := make(map[int]*TraceNode, len(spans))
+	var ordered []*TraceNode
+	for _, sp := range spans {
+		if _, ok := nodes[sp.SpanID]; !ok {
+			n := &TraceNode{Span: sp}
+			nodes[sp.SpanID] = n
+			ordered = append(ordered, n)
+		}
+	}
+	sortNodes(ordered)
+
+	f := &Forest{}
+	// Break cycles: follow every parent chain, marking each visited node
+	// with the chain it was reached from.
+	visitedBy := make(map[*TraceNode]int, len(ordered))
+	for i, n := range ordered {
+		var chain []*TraceNode
+		for cur := n; cur != nil && !cur.Cycle; cur = nodes[cur.Span.ParentID] {
+			if by, ok := visitedBy[cur]; ok {
+				if by == i {
+					f.Cycles = append(f.Cycles, breakCycle(chain, cur))
+				}
+				break
+			}
+			visitedBy[cur] = i
+			chain = append(chain, cur)
+			if cur.Span.ParentID == 0 {
+				break
+			}
+		}
+	}
+
+	for _, n := range ordered {
+		parent, ok := nodes[n.Span.ParentID]
+		switch {
+		case n.Span.ParentID == 0 || n.Cycle:
+			f.Roots = append(f.Roots, n)
+		case !ok:
+			n.Orphan = true
+			f.Roots = append(f.Roots, n)
+			f.Orphans = append(f.Orphans, n)
+		default:
+			parent.Children = append(parent.Children, n)
+		}
+	}
+	return f
+}
+
+// breakCycle makes the earliest node of the cycle starting at `start` (the
+// tail of `chain`) a root and returns the spans of the cycle.
+func breakCycle(chain []*TraceNode, start *TraceNode) []*MockSpan {
+	for i, n := range chain {
+		if n == start {
+			chain = chain[i:]
+			break
+		}
+	}
+	first := 0
+	for i, n := range chain {
+		if lessNode(n, chain[first]) {
+			first = i
+		}
+	}
+	chain[first].Cycle = true
+	cycle := make([]*MockSpan, 0, len(chain))
+	// The chain goes from child to parent; report it from the new root
+	// down.
+	for i := 0; i < len(chain); i++ {
+		cycle = append(cycle, chain[(first-i+len(chain))%len(chain)].Span)
+	}
+	return cycle
+}
+
+func lessNode(a, b *TraceNode) bool {
+	if !a.Span.StartTime.Equal(b.Span.StartTime) {
+		return a.Span.StartTime.Before(b.Span.StartTime)
+	}
+	return a.Span.SpanID < b.Span.SpanID
+}
+
+func sortNodes(nodes []*TraceNode) {
+	sort.Stable(byStart(nodes))
+}
+
+type byStart []*TraceNode
+
+func (s byStart) Len() int           { return len(s) }
+func (s byStart) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byStart) Less(i, j int) bool { return lessNode(s[i], s[j]) }
+
+// RenderOptions configures Forest.Render.
+type RenderOptions struct {
+	// Tags lists the tags to render, in order, for the spans that have them.
+	Tags []string
+
+	// Timings adds the start offset of every span relative to its tree's
+	// root and its duration. Leave it unset for output that does not depend
+	// on the clock.
+	Timings bool
+}
+
+// Render returns a deterministic, indented text form of the Forest, one
+// line per span:
+//
+//	GET /feed
+//	  query db.type="sql"
+//	  render
+//	retry [orphan]
+func (f *Forest) Render(opts RenderOptions) string {
+	var buf bytes.Buffer
+	for _, root := range f.Roots {
+		renderNode(&buf, root, root.Span.StartTime, 0, opts)
+	}
+	return buf.String()
+}
+
+func renderNode(buf *bytes.Buffer, n *TraceNode, origin time.Time, depth int, opts RenderOptions) {
+	sp := n.Span
+	buf.WriteString(strings.Repeat("  ", depth))
+	buf.WriteString(sp.OperationName)
+	if opts.Timings {
+		fmt.Fprintf(buf, " +%v %v", sp.StartTime.Sub(origin), sp.FinishTime.Sub(sp.StartTime))
+	}
+	for _, key := range opts.Tags {
+		if v, ok := sp.Tags[key]; ok {
+			fmt.Fprintf(buf, " %s=%#v", key, v)
+		}
+	}
+	if n.Orphan {
+		buf.WriteString(" [orphan]")
+	}
+	if n.Cycle {
+		buf.WriteString(" [cycle]")
+	}
+	buf.WriteByte('\n')
+
+	for _, c := range n.Children {
+		renderNode(buf, c, origin, depth+1, opts)
+	}
+}
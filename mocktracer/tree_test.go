@@ -0,0 +1,61 @@
+package mocktracer
+
+import (
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+func TestForest(t *testing.T) {
+	tracer := New()
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	root := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{OperationName: "GET /feed", StartTime: at(0)})
+	render := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{OperationName: "render", Parent: root, StartTime: at(20)})
+	query := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: "query",
+		Parent:        root,
+		StartTime:     at(5),
+		Tags:          opentracing.Tags{"db.type": "sql", "ignored": true},
+	})
+	query.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(15)})
+	render.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(30)})
+	root.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(40)})
+
+	orphan := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{OperationName: "retry", StartTime: at(50)}).(*MockSpan)
+	orphan.ParentID = -1
+	orphan.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(60)})
+
+	a := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{OperationName: "a", StartTime: at(70)}).(*MockSpan)
+	b := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{OperationName: "b", StartTime: at(71)}).(*MockSpan)
+	c := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{OperationName: "c", StartTime: at(72)}).(*MockSpan)
+	a.ParentID, b.ParentID, c.ParentID = b.SpanID, a.SpanID, b.SpanID
+	c.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(73)})
+	b.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(74)})
+	a.FinishWithOptions(opentracing.FinishOptions{FinishTime: at(75)})
+
+	forest := tracer.Forest()
+	if len(forest.Orphans) != 1 || forest.Orphans[0].Span != orphan {
+		t.Errorf("Unexpected orphans %v", forest.Orphans)
+	}
+	if len(forest.Cycles) != 1 || len(forest.Cycles[0]) != 2 || forest.Cycles[0][0] != a || forest.Cycles[0][1] != b {
+		t.Errorf("Unexpected cycles %v", forest.Cycles)
+	}
+
+	expected := `GET /feed +0s 40ms
+  query +5ms 10ms db.type="sql"
+  render +20ms 10ms
+retry +0s 10ms [orphan]
+a +0s 5ms [cycle]
+  b +1ms 3ms
+    c +2ms 1ms
+`
+	if got := forest.Render(RenderOptions{Tags: []string{"db.type"}, Timings: true}); got != expected {
+		t.Errorf("Unexpected rendering:\n%s\nexpected:\n%s", got, expected)
+	}
+	if got := BuildForest(tracer.Children(root.(*MockSpan))).Render(RenderOptions{}); got != "query [orphan]\nrender [orphan]\n" {
+		t.Errorf("Unexpected rendering:\n%s", got)
+	}
+}
@@ -0,0 +1,212 @@
+// Package multitracer provides an opentracing.Tracer that fans out to
+// several underlying Tracers, e.g. to report the same spans to two backends
+// during a migration.
+//
+// A Span started with a Parent that was not created by the same multitracer
+// Tracer starts a new trace in every underlying Tracer, since they could not
+// make sense of it.
+package multitracer
+
+import (
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Options allows creating a customized Tracer via NewWithOptions.
+type Options struct {
+	// Tracers are the underlying Tracers. The first one is the primary: it
+	// answers BaggageItem() calls and is the only one used by Inject()
+	// unless InjectAll is set. Required.
+	Tracers []opentracing.Tracer
+
+	// InjectAll makes Inject() encode the Span of every Tracer, in order,
+	// instead of only the primary's. For the Binary format the encodings
+	// are concatenated, which Join() handles as long as every Tracer reads
+	// exactly what it wrote. For the TextMap and HTTPHeaders formats the
+	// Tracers must use distinct keys: two Tracers of the same kind write the
+	// same keys twice, and Join() then fails with ErrTraceCorrupted.
+	InjectAll bool
+}
+
+// New returns a Tracer that fans out to `tracers`; the first one is the
+// primary (see Options).
+func New(tracers ...opentracing.Tracer) opentracing.Tracer {
+	return NewWithOptions(Options{Tracers: tracers})
+}
+
+// NewWithOptions creates a customized Tracer.
+func NewWithOptions(opts Options) opentracing.Tracer {
+	if len(opts.Tracers) == 0 {
+		panic("multitracer: at least one Tracer is required")
+	}
+	return &multiTracer{options: opts}
+}
+
+// Underlying returns the per-Tracer Spans behind a Span created by a
+// multitracer Tracer, in the order of Options.Tracers, or nil if `sp` was
+// created by some other Tracer.
+func Underlying(sp opentracing.Span) []opentracing.Span {
+	if ms, ok := sp.(*multiSpan); ok {
+		return ms.spans
+	}
+	return nil
+}
+
+type multiTracer struct {
+	options Options
+}
+
+func (t *multiTracer) StartSpan(operationName string) opentracing.Span {
+	return t.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: operationName,
+	})
+}
+
+func (t *multiTracer) StartSpanWithOptions(opts opentracing.StartSpanOptions) opentracing.Span {
+	parent, ok := opts.Parent.(*multiSpan)
+	if ok && parent.tracer != t {
+		parent = nil
+	}
+	sp := &multiSpan{tracer: t, spans: make([]opentracing.Span, len(t.options.Tracers))}
+	for i, tracer := range t.options.Tracers {
+		o := opts
+		o.Parent = nil
+		if parent != nil {
+			o.Parent = parent.spans[i]
+		}
+		// Each Tracer takes ownership of its Tags.
+		if opts.Tags != nil {
+			o.Tags = make(map[string]interface{}, len(opts.Tags))
+			for k, v := range opts.Tags {
+				o.Tags[k] = v
+			}
+		}
+		sp.spans[i] = tracer.StartSpanWithOptions(o)
+	}
+	return sp
+}
+
+func (t *multiTracer) Inject(sp opentracing.Span, format interface{}, carrier interface{}) error {
+	ms, ok := sp.(*multiSpan)
+	if !ok || ms.tracer != t {
+		return opentracing.ErrInvalidSpan
+	}
+	if !t.options.InjectAll {
+		return t.options.Tracers[0].Inject(ms.spans[0], format, carrier)
+	}
+	for i, tracer := range t.options.Tracers {
+		if err := tracer.Inject(ms.spans[i], format, carrier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Join asks every Tracer to join the trace in `carrier`. It succeeds if at
+// least one of them does; the others start a new trace. Otherwise it returns
+// the primary Tracer's error.
+func (t *multiTracer) Join(operationName string, format interface{}, carrier interface{}) (opentracing.Span, error) {
+	sp := &multiSpan{tracer: t, spans: make([]opentracing.Span, len(t.options.Tracers))}
+	var firstErr error
+	joined := false
+	for i, tracer := range t.options.Tracers {
+		span, err := tracer.Join(operationName, format, carrier)
+		if err != nil {
+			if i == 0 {
+				firstErr = err
+			}
+			continue
+		}
+		sp.spans[i] = span
+		joined = true
+	}
+	if !joined {
+		return nil, firstErr
+	}
+	for i, tracer := range t.options.Tracers {
+		if sp.spans[i] == nil {
+			sp.spans[i] = tracer.StartSpan(operationName)
+		}
+	}
+	return sp, nil
+}
+
+// multiSpan is the composite Span returned by multiTracer.
+type multiSpan struct {
+	tracer *multiTracer
+	spans  []opentracing.Span
+}
+
+func (s *multiSpan) SetOperationName(operationName string) opentracing.Span {
+	for _, sp := range s.spans {
+		sp.SetOperationName(operationName)
+	}
+	return s
+}
+
+func (s *multiSpan) SetTag(key string, value interface{}) opentracing.Span {
+	for _, sp := range s.spans {
+		sp.SetTag(key, value)
+	}
+	return s
+}
+
+func (s *multiSpan) Finish() {
+	for _, sp := range s.spans {
+		sp.Finish()
+	}
+}
+
+func (s *multiSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	for _, sp := range s.spans {
+		o := opts
+		// Each Span takes ownership of its BulkLogData.
+		if opts.BulkLogData != nil {
+			o.BulkLogData = append([]opentracing.LogData(nil), opts.BulkLogData...)
+		}
+		sp.FinishWithOptions(o)
+	}
+}
+
+func (s *multiSpan) LogEvent(event string) {
+	for _, sp := range s.spans {
+		sp.LogEvent(event)
+	}
+}
+
+func (s *multiSpan) LogEventWithPayload(event string, payload interface{}) {
+	for _, sp := range s.spans {
+		sp.LogEventWithPayload(event, payload)
+	}
+}
+
+func (s *multiSpan) Log(data opentracing.LogData) {
+	for _, sp := range s.spans {
+		sp.Log(data)
+	}
+}
+
+func (s *multiSpan) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	for _, sp := range s.spans {
+		sp.SetBaggageItem(restrictedKey, value)
+	}
+	return s
+}
+
+func (s *multiSpan) BaggageItem(restrictedKey string) string {
+	return s.spans[0].BaggageItem(restrictedKey)
+}
+
+func (s *multiSpan) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+// IsRecording belongs to the opentracing.RecordingSpan interface. A multiSpan
+// is recording if any of its Spans is.
+func (s *multiSpan) IsRecording() bool {
+	for _, sp := range s.spans {
+		if opentracing.IsRecording(sp) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package multitracer
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func TestFanOut(t *testing.T) {
+	rec := recorder.NewInMemoryRecorder()
+	mock := mocktracer.New()
+	tracer := New(basictracer.New(rec), mock)
+
+	parent := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: "parent",
+		Tags:          opentracing.Tags{"k": "v"},
+	})
+	parent.SetBaggageItem("user", "alice")
+	child := opentracing.StartChildSpan(parent, "child")
+	child.LogEvent("hello")
+	if child.BaggageItem("user") != "alice" {
+		t.Errorf("Child did not inherit baggage from the primary tracer")
+	}
+	child.Finish()
+	parent.SetOperationName("renamed").Finish()
+
+	spans := rec.GetSpans()
+	if len(spans) != 2 || len(mock.FinishedSpans) != 2 {
+		t.Fatalf("Unexpected finished spans: %v, %v", spans, mock.FinishedSpans)
+	}
+	if spans[0].ParentSpanID != spans[1].Context.SpanID || spans[1].Operation != "renamed" {
+		t.Errorf("Unexpected primary spans %+v", spans)
+	}
+	mockChild, mockParent := mock.FinishedSpans[0], mock.FinishedSpans[1]
+	if mockChild.ParentID != mockParent.SpanID || mockParent.Tags["k"] != "v" || len(mockChild.Logs) != 1 {
+		t.Errorf("Unexpected secondary spans %+v, %+v", mockChild, mockParent)
+	}
+	if len(Underlying(parent)) != 2 || Underlying(opentracing.NoopTracer{}.StartSpan("x")) != nil {
+		t.Error("Unexpected Underlying() result")
+	}
+
+	// A foreign parent is dropped rather than forwarded.
+	orphan := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: "orphan",
+		Parent:        mock.StartSpan("foreign"),
+	})
+	if mockOrphan := Underlying(orphan)[1].(*mocktracer.MockSpan); mockOrphan.ParentID != 0 {
+		t.Errorf("Unexpected parent %v", mockOrphan.ParentID)
+	}
+}
+
+func TestInjectJoin(t *testing.T) {
+	primary := basictracer.New(recorder.NewInMemoryRecorder())
+	secondary := basictracer.New(recorder.NewInMemoryRecorder())
+
+	for _, injectAll := range []bool{false, true} {
+		tracer := NewWithOptions(Options{
+			Tracers:   []opentracing.Tracer{primary, secondary},
+			InjectAll: injectAll,
+		})
+		sp := tracer.StartSpan("client")
+		carrier := &bytes.Buffer{}
+		if err := tracer.Inject(sp, opentracing.Binary, carrier); err != nil {
+			t.Fatal(err)
+		}
+		joined, err := tracer.Join("server", opentracing.Binary, carrier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		spans, joinedSpans := Underlying(sp), Underlying(joined)
+		for i := range spans {
+			sameTrace := spans[i].(basictracer.Span).Context().TraceID == joinedSpans[i].(basictracer.Span).Context().TraceID
+			if expected := i == 0 || injectAll; sameTrace != expected {
+				t.Errorf("InjectAll=%v: tracer %v joined the trace: %v", injectAll, i, sameTrace)
+			}
+		}
+	}
+}
+
+func TestInjectAllTextMap(t *testing.T) {
+	basic := basictracer.New(recorder.NewInMemoryRecorder())
+	for _, test := range []struct {
+		tracers []opentracing.Tracer
+		err     error
+	}{
+		{[]opentracing.Tracer{basic, mocktracer.New()}, nil},
+		// Tracers of the same kind write the same keys twice.
+		{[]opentracing.Tracer{basic, basictracer.New(recorder.NewInMemoryRecorder())}, opentracing.ErrTraceCorrupted},
+	} {
+		tracer := NewWithOptions(Options{Tracers: test.tracers, InjectAll: true})
+		carrier := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+		if err := tracer.Inject(tracer.StartSpan("client"), opentracing.TextMap, carrier); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tracer.Join("server", opentracing.TextMap, carrier); err != test.err {
+			t.Errorf("Expected %v, got %v", test.err, err)
+		}
+	}
+}
+
+func TestJoinFailure(t *testing.T) {
+	tracer := New(basictracer.New(recorder.NewInMemoryRecorder()))
+	carrier := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+	if _, err := tracer.Join("x", opentracing.TextMap, carrier); err != opentracing.ErrTraceNotFound {
+		t.Errorf("Expected ErrTraceNotFound, got %v", err)
+	}
+	if err := tracer.Inject(opentracing.NoopTracer{}.StartSpan("x"), opentracing.TextMap, carrier); err != opentracing.ErrInvalidSpan {
+		t.Errorf("Expected ErrInvalidSpan, got %v", err)
+	}
+}
@@ -1,7 +1,8 @@
 package opentracing
 
 // A NoopTracer is a trivial implementation of Tracer for which all operations
-// are no-ops.
+// are no-ops. Its Spans are RecordingSpans that are never recording, and
+// none of its methods allocate.
 type NoopTracer struct{}
 
 type noopSpan struct{}
@@ -26,6 +27,7 @@ func (n noopSpan) LogEventWithPayload(event string, payload interface{}) {}
 func (n noopSpan) Log(data LogData)                                      {}
 func (n noopSpan) SetOperationName(operationName string) Span            { return n }
 func (n noopSpan) Tracer() Tracer                                        { return defaultNoopTracer }
+func (n noopSpan) IsRecording() bool                                     { return false }
 
 // StartSpan belongs to the Tracer interface.
 func (n NoopTracer) StartSpan(operationName string) Span {
@@ -42,7 +44,9 @@ func (n NoopTracer) Inject(sp Span, format interface{}, carrier interface{}) err
 	return nil
 }
 
-// Join belongs to the Tracer interface.
+// Join belongs to the Tracer interface. Since a NoopTracer never propagates
+// anything it returns ErrTraceNotFound, but along with a no-op Span rather
+// than nil, so that callers which ignore the error need not special-case it.
 func (n NoopTracer) Join(operationName string, format interface{}, carrier interface{}) (Span, error) {
-	return nil, ErrTraceNotFound
+	return defaultNoopSpan, ErrTraceNotFound
 }
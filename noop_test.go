@@ -0,0 +1,75 @@
+package opentracing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNoopIsRecording(t *testing.T) {
+	if IsRecording(nil) {
+		t.Error("A nil Span should not be recording")
+	}
+	if IsRecording(NoopTracer{}.StartSpan("op")) {
+		t.Error("A noop Span should not be recording")
+	}
+}
+
+func TestNoopJoin(t *testing.T) {
+	sp, err := NoopTracer{}.Join("op", TextMap, nil)
+	if err != ErrTraceNotFound {
+		t.Errorf("Expected ErrTraceNotFound, got %v", err)
+	}
+	sp.SetTag("component", "test")
+	sp.Finish()
+}
+
+func TestNoopAllocs(t *testing.T) {
+	tracer := NoopTracer{}
+	parent := tracer.StartSpan("parent")
+	startTime := time.Now()
+	for name, f := range map[string]func(){
+		"StartSpan": func() { tracer.StartSpan("op") },
+		"StartSpanWithOptions": func() {
+			tracer.StartSpanWithOptions(StartSpanOptions{
+				OperationName: "op",
+				Parent:        parent,
+				StartTime:     startTime,
+			})
+		},
+		"SetTag":         func() { parent.SetTag("component", "test") },
+		"LogEvent":       func() { parent.LogEvent("event") },
+		"SetBaggageItem": func() { parent.SetBaggageItem("user", "alice") },
+		"Finish":         func() { parent.Finish() },
+		"Join":           func() { tracer.Join("op", TextMap, nil) },
+		"IsRecording": func() {
+			if IsRecording(parent) {
+				parent.SetTag("expensive", make([]byte, 1024))
+			}
+		},
+	} {
+		if allocs := testing.AllocsPerRun(100, f); allocs != 0 {
+			t.Errorf("%s: expected no allocations, got %v", name, allocs)
+		}
+	}
+}
+
+func BenchmarkNoopStartFinish(b *testing.B) {
+	tracer := NoopTracer{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		sp := tracer.StartSpan("op")
+		sp.SetTag("component", "test")
+		sp.LogEvent("event")
+		sp.Finish()
+	}
+}
+
+func BenchmarkNoopIsRecording(b *testing.B) {
+	sp := NoopTracer{}.StartSpan("op")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if IsRecording(sp) {
+			sp.SetTag("expensive", make([]byte, 1024))
+		}
+	}
+}
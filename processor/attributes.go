@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"os"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Tag keys set by ProcessAttributes.
+const (
+	HostnameTagKey       = "hostname"
+	ProcessPIDTagKey     = "process.pid"
+	ServiceVersionTagKey = "service.version"
+)
+
+// Attributes returns a SpanProcessor that adds `tags` to every finished span.
+// Tags already set on a span take precedence.
+func Attributes(tags opentracing.Tags) SpanProcessor {
+	return Funcs{Finish: func(span *recorder.RawSpan) bool {
+		merged := make(opentracing.Tags, len(span.Tags)+len(tags))
+		for k, v := range tags {
+			merged[k] = v
+		}
+		for k, v := range span.Tags {
+			merged[k] = v
+		}
+		span.Tags = merged
+		return true
+	}}
+}
+
+// ProcessAttributes returns an Attributes processor describing the current
+// process: its host name, pid and, if non-empty, `serviceVersion`.
+func ProcessAttributes(serviceVersion string) SpanProcessor {
+	tags := opentracing.Tags{
+		ProcessPIDTagKey: os.Getpid(),
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		tags[HostnameTagKey] = hostname
+	}
+	if serviceVersion != "" {
+		tags[ServiceVersionTagKey] = serviceVersion
+	}
+	return Attributes(tags)
+}
+
+// AllowTags returns a SpanProcessor that removes every tag whose key does not
+// match one of `patterns`. A pattern ending in "*" matches any key with that
+// prefix; other patterns must match exactly.
+func AllowTags(patterns ...string) SpanProcessor {
+	return tagFilter(patterns, true)
+}
+
+// DenyTags returns a SpanProcessor that removes every tag whose key matches
+// one of `patterns` (see AllowTags for the pattern syntax).
+func DenyTags(patterns ...string) SpanProcessor {
+	return tagFilter(patterns, false)
+}
+
+func tagFilter(patterns []string, allow bool) SpanProcessor {
+	return Funcs{Finish: func(span *recorder.RawSpan) bool {
+		filtered := make(opentracing.Tags, len(span.Tags))
+		for k, v := range span.Tags {
+			if matchesAny(patterns, k) == allow {
+				filtered[k] = v
+			}
+		}
+		span.Tags = filtered
+		return true
+	}}
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(key, p[:len(p)-1]) {
+				return true
+			}
+		} else if p == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns a SpanProcessor that drops every span for which `keep`
+// returns false.
+func Filter(keep func(span *recorder.RawSpan) bool) SpanProcessor {
+	return Funcs{Finish: keep}
+}
@@ -0,0 +1,185 @@
+package processor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Exporter sends batches of finished spans to a tracing backend.
+type Exporter interface {
+	// ExportSpans exports `spans`. It is never called concurrently by a
+	// Batcher, and must not retain `spans` after returning.
+	ExportSpans(spans []recorder.RawSpan) error
+}
+
+// BatchOptions configures a Batcher.
+type BatchOptions struct {
+	// MaxQueueSize bounds the number of spans waiting to be exported; spans
+	// recorded while the queue is full are dropped. Defaults to 2048.
+	MaxQueueSize int
+
+	// MaxBatchSize bounds the number of spans handed to a single
+	// Exporter.ExportSpans() call. A full batch is exported immediately.
+	// Defaults to 512.
+	MaxBatchSize int
+
+	// FlushInterval is the maximum delay between a span being recorded and
+	// being exported. Defaults to five seconds.
+	FlushInterval time.Duration
+
+	// OnError, if non-nil, is called with every error returned by the
+	// Exporter.
+	OnError func(err error)
+}
+
+// BatcherStats holds counters describing the activity of a Batcher.
+type BatcherStats struct {
+	Queued   int
+	Exported int64
+	// Dropped counts the spans dropped because the queue was full or the
+	// Batcher closed, and those of the batches that failed to export.
+	Dropped      int64
+	ExportErrors int64
+}
+
+// Batcher is a recorder.SpanRecorder that queues spans and exports them in
+// batches from a background goroutine. Call Close to flush the queue and stop
+// the goroutine.
+type Batcher struct {
+	exporter Exporter
+	options  BatchOptions
+
+	lock   sync.Mutex
+	queue  []recorder.RawSpan
+	stats  BatcherStats
+	closed bool
+
+	exportLock sync.Mutex // serializes calls to exporter
+	batchReady chan struct{}
+	done       chan struct{}
+	stopped    chan struct{}
+}
+
+// NewBatcher returns a running Batcher that exports to `exporter`.
+func NewBatcher(exporter Exporter, opts BatchOptions) *Batcher {
+	if opts.MaxQueueSize <= 0 {
+		opts.MaxQueueSize = 2048
+	}
+	if opts.MaxBatchSize <= 0 {
+		opts.MaxBatchSize = 512
+	}
+	if opts.MaxBatchSize > opts.MaxQueueSize {
+		opts.MaxBatchSize = opts.MaxQueueSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	b := &Batcher{
+		exporter:   exporter,
+		options:    opts,
+		batchReady: make(chan struct{}, 1),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// RecordSpan belongs to the recorder.SpanRecorder interface. It never
+// blocks on the Exporter.
+func (b *Batcher) RecordSpan(span recorder.RawSpan) {
+	b.lock.Lock()
+	if b.closed || len(b.queue) >= b.options.MaxQueueSize {
+		b.stats.Dropped++
+		b.lock.Unlock()
+		return
+	}
+	b.queue = append(b.queue, span)
+	full := len(b.queue) >= b.options.MaxBatchSize
+	b.lock.Unlock()
+
+	if full {
+		select {
+		case b.batchReady <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Flush synchronously exports every queued span.
+func (b *Batcher) Flush() {
+	b.exportLock.Lock()
+	defer b.exportLock.Unlock()
+	for {
+		b.lock.Lock()
+		n := len(b.queue)
+		if n > b.options.MaxBatchSize {
+			n = b.options.MaxBatchSize
+		}
+		batch := make([]recorder.RawSpan, n)
+		copy(batch, b.queue)
+		b.queue = b.queue[n:]
+		b.lock.Unlock()
+		if n == 0 {
+			return
+		}
+		b.export(batch)
+	}
+}
+
+// Close flushes the queue and stops the background goroutine. Spans
+// recorded after Close are dropped.
+func (b *Batcher) Close() {
+	b.lock.Lock()
+	if b.closed {
+		b.lock.Unlock()
+		return
+	}
+	b.closed = true
+	b.lock.Unlock()
+	close(b.done)
+	<-b.stopped
+	b.Flush()
+}
+
+// Stats returns a snapshot of the Batcher's counters.
+func (b *Batcher) Stats() BatcherStats {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	stats := b.stats
+	stats.Queued = len(b.queue)
+	return stats
+}
+
+func (b *Batcher) export(batch []recorder.RawSpan) {
+	err := b.exporter.ExportSpans(batch)
+	b.lock.Lock()
+	if err != nil {
+		b.stats.ExportErrors++
+		b.stats.Dropped += int64(len(batch))
+	} else {
+		b.stats.Exported += int64(len(batch))
+	}
+	b.lock.Unlock()
+	if err != nil && b.options.OnError != nil {
+		b.options.OnError(err)
+	}
+}
+
+func (b *Batcher) run() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.options.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush()
+		case <-b.batchReady:
+			b.Flush()
+		case <-b.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package processor provides a chainable pipeline of SpanProcessors that
+// sits between a Tracer and the exporter of its finished spans, so that spans
+// can be enriched, redacted, filtered and batched.
+//
+// A Pipeline is wired into a basictracer like so:
+//
+//	pipeline := processor.NewPipeline(
+//	    processor.NewBatcher(exporter, processor.BatchOptions{}),
+//	    processor.ProcessAttributes("v1.2.3"),
+//	    processor.DenyTags("http.url"),
+//	)
+//	tracer := basictracer.NewWithOptions(basictracer.Options{
+//	    Recorder: pipeline,
+//	    OnStart:  pipeline.OnStart,
+//	})
+package processor
+
+import (
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// SpanProcessor observes Spans as they start and finish.
+//
+// SpanProcessors must be safe for concurrent use.
+type SpanProcessor interface {
+	// OnStart is called with every new Span before it is returned to the
+	// application (see basictracer.Options.OnStart).
+	OnStart(sp basictracer.Span)
+
+	// OnFinish is called with every finished Span. It may modify `span`,
+	// but must copy its maps and slices before doing so since they may be
+	// shared (see recorder.RawSpan). Returning false drops the Span: later
+	// processors and the pipeline's recorder never see it.
+	OnFinish(span *recorder.RawSpan) bool
+}
+
+// Funcs adapts a pair of ordinary functions to the SpanProcessor interface.
+// Either may be nil.
+type Funcs struct {
+	Start  func(sp basictracer.Span)
+	Finish func(span *recorder.RawSpan) bool
+}
+
+// OnStart belongs to the SpanProcessor interface.
+func (f Funcs) OnStart(sp basictracer.Span) {
+	if f.Start != nil {
+		f.Start(sp)
+	}
+}
+
+// OnFinish belongs to the SpanProcessor interface.
+func (f Funcs) OnFinish(span *recorder.RawSpan) bool {
+	if f.Finish != nil {
+		return f.Finish(span)
+	}
+	return true
+}
+
+// Pipeline runs a chain of SpanProcessors and hands the spans that survive
+// it to a SpanRecorder.
+//
+// Pipeline is itself a SpanProcessor, so pipelines can be nested.
+type Pipeline struct {
+	processors []SpanProcessor
+	next       recorder.SpanRecorder
+}
+
+// NewPipeline returns a Pipeline that runs `processors` in order and records
+// the surviving spans to `next`, which may be nil.
+func NewPipeline(next recorder.SpanRecorder, processors ...SpanProcessor) *Pipeline {
+	return &Pipeline{
+		processors: processors,
+		next:       next,
+	}
+}
+
+// OnStart belongs to the SpanProcessor interface. Its signature matches
+// basictracer.Options.OnStart.
+func (p *Pipeline) OnStart(sp basictracer.Span) {
+	for _, proc := range p.processors {
+		proc.OnStart(sp)
+	}
+}
+
+// OnFinish belongs to the SpanProcessor interface. It runs the processors
+// but does not record the span.
+func (p *Pipeline) OnFinish(span *recorder.RawSpan) bool {
+	for _, proc := range p.processors {
+		if !proc.OnFinish(span) {
+			return false
+		}
+	}
+	return true
+}
+
+// RecordSpan belongs to the recorder.SpanRecorder interface.
+func (p *Pipeline) RecordSpan(span recorder.RawSpan) {
+	if p.OnFinish(&span) && p.next != nil {
+		p.next.RecordSpan(span)
+	}
+}
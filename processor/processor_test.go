@@ -0,0 +1,130 @@
+package processor
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func TestPipeline(t *testing.T) {
+	rec := recorder.NewInMemoryRecorder()
+	var started []string
+	pipeline := NewPipeline(rec,
+		Funcs{Start: func(sp basictracer.Span) {
+			started = append(started, sp.Operation())
+			sp.SetTag("started", true)
+		}},
+		ProcessAttributes("v1"),
+		DenyTags("secret.*"),
+		Filter(func(span *recorder.RawSpan) bool { return span.Operation != "health" }),
+	)
+	opts := basictracer.DefaultOptions()
+	opts.Recorder = pipeline
+	opts.OnStart = pipeline.OnStart
+	tracer := basictracer.NewWithOptions(opts)
+
+	tracer.StartSpan("health").Finish()
+	tracer.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: "GetFeed",
+		Tags: opentracing.Tags{
+			"secret.token":       "hunter2",
+			ServiceVersionTagKey: "override",
+		},
+	}).Finish()
+
+	if len(started) != 2 {
+		t.Errorf("Unexpected OnStart calls: %v", started)
+	}
+	spans := rec.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("Recorded %v spans, expected 1", len(spans))
+	}
+	tags := spans[0].Tags
+	if _, ok := tags["secret.token"]; ok {
+		t.Error("Expected secret.token to be removed")
+	}
+	if tags[ProcessPIDTagKey] != os.Getpid() || tags[ServiceVersionTagKey] != "override" || tags["started"] != true {
+		t.Errorf("Unexpected tags: %v", tags)
+	}
+}
+
+func TestAllowTags(t *testing.T) {
+	span := recorder.RawSpan{Tags: opentracing.Tags{"http.url": "/", "http.method": "GET", "user": "x"}}
+	AllowTags("http.method").OnFinish(&span)
+	if len(span.Tags) != 1 || span.Tags["http.method"] != "GET" {
+		t.Errorf("Unexpected tags: %v", span.Tags)
+	}
+}
+
+type fakeExporter struct {
+	lock    sync.Mutex
+	batches [][]recorder.RawSpan
+	err     error
+}
+
+func (e *fakeExporter) ExportSpans(spans []recorder.RawSpan) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.batches = append(e.batches, append([]recorder.RawSpan(nil), spans...))
+	return e.err
+}
+
+func (e *fakeExporter) batchSizes() []int {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	var sizes []int
+	for _, b := range e.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func TestBatcher(t *testing.T) {
+	exporter := &fakeExporter{}
+	b := NewBatcher(exporter, BatchOptions{
+		MaxQueueSize:  5,
+		MaxBatchSize:  2,
+		FlushInterval: time.Hour,
+	})
+	// Exports are asynchronous, so hold the export lock to let the queue
+	// fill up deterministically.
+	b.exportLock.Lock()
+	for i := 0; i < 7; i++ {
+		b.RecordSpan(recorder.RawSpan{Operation: "x"})
+	}
+	b.exportLock.Unlock()
+	b.Close()
+	b.RecordSpan(recorder.RawSpan{Operation: "late"})
+
+	stats := b.Stats()
+	if stats.Exported != 5 || stats.Dropped != 3 || stats.Queued != 0 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	for _, size := range exporter.batchSizes() {
+		if size > 2 {
+			t.Errorf("Batch of %v spans exceeds MaxBatchSize", size)
+		}
+	}
+}
+
+func TestBatcherErrors(t *testing.T) {
+	errExport := errors.New("collector unavailable")
+	var reported []error
+	b := NewBatcher(&fakeExporter{err: errExport}, BatchOptions{
+		OnError: func(err error) { reported = append(reported, err) },
+	})
+	b.RecordSpan(recorder.RawSpan{})
+	b.Close()
+	if stats := b.Stats(); stats.ExportErrors != 1 || stats.Exported != 0 || stats.Dropped != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if len(reported) != 1 || reported[0] != errExport {
+		t.Errorf("Unexpected reported errors: %v", reported)
+	}
+}
@@ -0,0 +1,124 @@
+// Package recorder defines the finished-span data model shared by Tracer
+// implementations, span processors and exporters.
+package recorder
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// TraceID is a 128-bit trace identifier. Tracers that use 64-bit trace IDs
+// leave High set to zero.
+type TraceID struct {
+	High uint64
+	Low  uint64
+}
+
+// IsZero returns true if `id` is the zero (invalid) TraceID.
+func (id TraceID) IsZero() bool {
+	return id.High == 0 && id.Low == 0
+}
+
+// String returns the lowercase hex encoding of `id`: 16 characters for a
+// 64-bit ID and 32 characters otherwise.
+func (id TraceID) String() string {
+	if id.High == 0 {
+		return fmt.Sprintf("%016x", id.Low)
+	}
+	return fmt.Sprintf("%016x%016x", id.High, id.Low)
+}
+
+// SpanContext holds the data that identifies a Span and is propagated to its
+// children.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  uint64
+
+	// Sampled is the head sampling decision for the trace.
+	Sampled bool
+
+	// Baggage holds the Span's baggage items; may be nil.
+	Baggage map[string]string
+}
+
+// RawSpan is an immutable snapshot of a finished Span.
+//
+// SpanRecorders receive RawSpans by value, but the maps and slices they hold
+// may be shared with other recorders; they must be copied before being
+// modified.
+type RawSpan struct {
+	Context SpanContext
+
+	// ParentSpanID is the SpanID of the parent Span, or zero for a trace
+	// root.
+	ParentSpanID uint64
+
+	// LocalRoot is true if the Span has no parent within this process: it
+	// is either a trace root or was created by Tracer.Join().
+	LocalRoot bool
+
+	Operation string
+	Start     time.Time
+	Duration  time.Duration
+	Tags      opentracing.Tags
+	Logs      []opentracing.LogData
+}
+
+// Finish returns the finish timestamp of the Span.
+func (s *RawSpan) Finish() time.Time {
+	return s.Start.Add(s.Duration)
+}
+
+// SpanRecorder receives finished Spans.
+//
+// RecordSpan may be called concurrently, and implementations should not
+// block for long since they are typically invoked from Span.Finish().
+type SpanRecorder interface {
+	RecordSpan(span RawSpan)
+}
+
+// SpanRecorderFunc adapts an ordinary function to the SpanRecorder interface.
+type SpanRecorderFunc func(span RawSpan)
+
+// RecordSpan belongs to the SpanRecorder interface.
+func (f SpanRecorderFunc) RecordSpan(span RawSpan) {
+	f(span)
+}
+
+// InMemoryRecorder is a SpanRecorder that retains every RawSpan it is given.
+type InMemoryRecorder struct {
+	lock  sync.Mutex
+	spans []RawSpan
+}
+
+// NewInMemoryRecorder returns an empty InMemoryRecorder.
+func NewInMemoryRecorder() *InMemoryRecorder {
+	return &InMemoryRecorder{}
+}
+
+// RecordSpan belongs to the SpanRecorder interface.
+func (r *InMemoryRecorder) RecordSpan(span RawSpan) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+// GetSpans returns a copy of the recorded spans, in the order they were
+// recorded.
+func (r *InMemoryRecorder) GetSpans() []RawSpan {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	spans := make([]RawSpan, len(r.spans))
+	copy(spans, r.spans)
+	return spans
+}
+
+// Reset discards all recorded spans.
+func (r *InMemoryRecorder) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.spans = nil
+}
@@ -0,0 +1,267 @@
+// Package redact removes personally identifiable information and secrets
+// from finished spans before they reach a recorder or exporter.
+//
+// A Redactor applies an ordered list of Rules to the tags, baggage, log
+// events and log payloads of a recorder.RawSpan. It implements
+// processor.SpanProcessor, so it is typically the first stage of a
+// processor.Pipeline.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Action is what a Rule does to the data it matches.
+type Action int
+
+const (
+	// Mask replaces the matched data with Options.Mask.
+	Mask Action = iota
+	// Hash replaces the matched data with a keyed hash of it, so that equal
+	// values can still be correlated.
+	Hash
+	// Drop removes the whole tag, baggage item, payload entry or log record.
+	Drop
+)
+
+func (a Action) String() string {
+	switch a {
+	case Mask:
+		return "mask"
+	case Hash:
+		return "hash"
+	case Drop:
+		return "drop"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// Rule selects sensitive data. A Rule matches a value if its Key matches the
+// key the value is stored under, or if Match returns true; in both cases the
+// Action applies to the whole value. Otherwise, if Value matches part of the
+// (string form of the) value, the Action applies to the matching substrings
+// only (or to the whole value for Drop).
+type Rule struct {
+	// Name identifies the Rule in Findings.
+	Name string
+
+	Key   *regexp.Regexp
+	Value *regexp.Regexp
+	Match func(key string, value interface{}) bool
+
+	Action Action
+}
+
+// Finding describes one redaction.
+type Finding struct {
+	Rule   string
+	Action Action
+
+	// Location is one of "tag", "baggage", "log.event" or "log.payload".
+	Location string
+
+	// Key is the tag or baggage key, or the dotted path of the redacted
+	// entry within a log payload map.
+	Key string
+}
+
+// Options configures a Redactor.
+type Options struct {
+	// Rules are tried in order; the first Rule matching a value wins.
+	Rules []Rule
+
+	// Mask replaces masked data. Defaults to "[REDACTED]".
+	Mask string
+
+	// HashKey keys the HMAC-SHA256 used by the Hash action.
+	HashKey []byte
+
+	// DryRun reports Findings without modifying spans, to evaluate rules
+	// against production traffic or in tests.
+	DryRun bool
+
+	// OnRedact, if non-nil, is called with the Findings of every span that
+	// had at least one.
+	OnRedact func(span *recorder.RawSpan, findings []Finding)
+}
+
+// Redactor applies Rules to spans.
+type Redactor struct {
+	options Options
+}
+
+// New returns a Redactor configured by `opts`.
+func New(opts Options) *Redactor {
+	if opts.Mask == "" {
+		opts.Mask = "[REDACTED]"
+	}
+	return &Redactor{options: opts}
+}
+
+// OnStart belongs to the processor.SpanProcessor interface.
+func (r *Redactor) OnStart(sp basictracer.Span) {}
+
+// OnFinish belongs to the processor.SpanProcessor interface. It redacts
+// `span` and never drops it.
+func (r *Redactor) OnFinish(span *recorder.RawSpan) bool {
+	r.Redact(span)
+	return true
+}
+
+// Redact redacts `span` in place (replacing rather than modifying its maps
+// and slices, which may be shared) and returns what was redacted. In DryRun
+// mode `span` is left untouched.
+func (r *Redactor) Redact(span *recorder.RawSpan) []Finding {
+	var findings []Finding
+
+	tags := make(opentracing.Tags, len(span.Tags))
+	for _, k := range sortedKeys(span.Tags) {
+		if v, keep := r.value("tag", k, k, span.Tags[k], &findings); keep {
+			tags[k] = v
+		}
+	}
+
+	baggage := make(map[string]string, len(span.Context.Baggage))
+	for _, k := range sortedStringKeys(span.Context.Baggage) {
+		if redacted, keep := r.value("baggage", k, k, span.Context.Baggage[k], &findings); keep {
+			baggage[k] = redacted.(string)
+		}
+	}
+
+	logs := make([]opentracing.LogData, 0, len(span.Logs))
+	for _, ld := range span.Logs {
+		event, keep := r.value("log.event", "event", "", ld.Event, &findings)
+		if !keep {
+			continue
+		}
+		ld.Event = event.(string)
+		if ld.Payload != nil {
+			if ld.Payload, keep = r.value("log.payload", "payload", "", ld.Payload, &findings); !keep {
+				continue
+			}
+		}
+		logs = append(logs, ld)
+	}
+
+	if len(findings) == 0 {
+		return nil
+	}
+	if !r.options.DryRun {
+		span.Tags = tags
+		if span.Context.Baggage != nil {
+			span.Context.Baggage = baggage
+		}
+		if span.Logs != nil {
+			span.Logs = logs
+		}
+	}
+	if r.options.OnRedact != nil {
+		r.options.OnRedact(span, findings)
+	}
+	return findings
+}
+
+// value redacts `v`, stored under `key` at `path`. It returns the redacted
+// value and false if the value must be dropped.
+func (r *Redactor) value(location, key, path string, v interface{}, findings *[]Finding) (interface{}, bool) {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		rval := make(map[string]interface{}, len(typed))
+		for _, k := range sortedKeys(typed) {
+			if redacted, keep := r.value(location, k, joinPath(path, k), typed[k], findings); keep {
+				rval[k] = redacted
+			}
+		}
+		return rval, true
+	case map[string]string:
+		rval := make(map[string]string, len(typed))
+		for _, k := range sortedStringKeys(typed) {
+			if redacted, keep := r.value(location, k, joinPath(path, k), typed[k], findings); keep {
+				rval[k] = redacted.(string)
+			}
+		}
+		return rval, true
+	}
+
+	for _, rule := range r.options.Rules {
+		whole := (rule.Key != nil && rule.Key.MatchString(key)) ||
+			(rule.Match != nil && rule.Match(key, v))
+		var s string
+		if !whole {
+			if rule.Value == nil {
+				continue
+			}
+			s = stringValue(v)
+			if !rule.Value.MatchString(s) {
+				continue
+			}
+		}
+		*findings = append(*findings, Finding{
+			Rule:     rule.Name,
+			Action:   rule.Action,
+			Location: location,
+			Key:      path,
+		})
+		switch {
+		case rule.Action == Drop:
+			return nil, false
+		case whole:
+			return r.replace(stringValue(v), rule.Action), true
+		default:
+			return rule.Value.ReplaceAllStringFunc(s, func(match string) string {
+				return r.replace(match, rule.Action)
+			}), true
+		}
+	}
+	return v, true
+}
+
+func (r *Redactor) replace(s string, action Action) string {
+	if action == Hash {
+		mac := hmac.New(sha256.New, r.options.HashKey)
+		mac.Write([]byte(s))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+	return r.options.Mask
+}
+
+func stringValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return fmt.Sprint(v)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+package redact
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/processor"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func testSpan() recorder.RawSpan {
+	return recorder.RawSpan{
+		Context: recorder.SpanContext{
+			Baggage: map[string]string{"user.email": "alice@example.com", "tenant": "acme"},
+		},
+		Operation: "checkout",
+		Tags: opentracing.Tags{
+			"http.url":      "/pay?card=4111 1111 1111 1111",
+			"Authorization": "Bearer abc",
+			"session.id":    "s-123",
+			"retries":       3,
+		},
+		Logs: []opentracing.LogData{
+			{Event: "charged alice@example.com"},
+			{Event: "debug", Payload: map[string]interface{}{
+				"request": map[string]interface{}{"password": "hunter2", "amount": 10},
+			}},
+			{Event: "session s-123 expired"},
+		},
+	}
+}
+
+func TestRedact(t *testing.T) {
+	r := New(Options{
+		Rules: []Rule{
+			Secrets,
+			Emails,
+			CardNumbers,
+			{Name: "sessions", Value: regexp.MustCompile(`s-\d+`), Action: Drop},
+		},
+		HashKey: []byte("k"),
+	})
+	span := testSpan()
+	original := testSpan()
+	shared := span.Tags
+	findings := r.Redact(&span)
+
+	if !reflect.DeepEqual(shared, original.Tags) {
+		t.Error("Redact modified the original tags map")
+	}
+	expectedTags := opentracing.Tags{
+		"http.url":      "/pay?card=[REDACTED]",
+		"Authorization": "[REDACTED]",
+		"retries":       3,
+	}
+	if !reflect.DeepEqual(span.Tags, expectedTags) {
+		t.Errorf("Unexpected tags %v", span.Tags)
+	}
+	if email := span.Context.Baggage["user.email"]; !strings.HasPrefix(email, "hmac:") || email != r.replace("alice@example.com", Hash) {
+		t.Errorf("Unexpected baggage %v", span.Context.Baggage)
+	}
+	if len(span.Logs) != 2 {
+		t.Fatalf("Unexpected logs %v", span.Logs)
+	}
+	if span.Logs[0].Event != "charged "+span.Context.Baggage["user.email"] {
+		t.Errorf("Hashes of equal values differ: %q", span.Logs[0].Event)
+	}
+	expectedPayload := map[string]interface{}{
+		"request": map[string]interface{}{"password": "[REDACTED]", "amount": 10},
+	}
+	if !reflect.DeepEqual(span.Logs[1].Payload, expectedPayload) {
+		t.Errorf("Unexpected payload %v", span.Logs[1].Payload)
+	}
+
+	var keys []string
+	for _, f := range findings {
+		keys = append(keys, f.Location+":"+f.Key+":"+f.Action.String())
+	}
+	expected := []string{
+		"tag:Authorization:mask",
+		"tag:http.url:mask",
+		"tag:session.id:drop",
+		"baggage:user.email:hash",
+		"log.event::hash",
+		"log.payload:request.password:mask",
+		"log.event::drop",
+	}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("Unexpected findings\n%v\nexpected\n%v", keys, expected)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	var reported []Finding
+	r := New(Options{
+		Rules:    []Rule{Secrets},
+		DryRun:   true,
+		OnRedact: func(span *recorder.RawSpan, findings []Finding) { reported = findings },
+	})
+	span := testSpan()
+	findings := r.Redact(&span)
+	if !reflect.DeepEqual(span, testSpan()) {
+		t.Errorf("DryRun modified the span: %+v", span)
+	}
+	if len(findings) != 2 || !reflect.DeepEqual(findings, reported) {
+		t.Errorf("Unexpected findings %v, reported %v", findings, reported)
+	}
+}
+
+func TestMatchFunc(t *testing.T) {
+	r := New(Options{Rules: []Rule{{
+		Name:   "ints",
+		Match:  func(key string, value interface{}) bool { _, ok := value.(int); return ok },
+		Action: Mask,
+	}}})
+	span := testSpan()
+	r.Redact(&span)
+	if span.Tags["retries"] != "[REDACTED]" {
+		t.Errorf("Unexpected tags %v", span.Tags)
+	}
+}
+
+func TestProcessor(t *testing.T) {
+	rec := recorder.NewInMemoryRecorder()
+	var _ processor.SpanProcessor = &Redactor{}
+	tracer := basictracer.New(processor.NewPipeline(rec, New(Options{Rules: []Rule{Secrets}})))
+	tracer.StartSpan("login").SetTag("password", "hunter2").Finish()
+	spans := rec.GetSpans()
+	if len(spans) != 1 || spans[0].Tags["password"] != "[REDACTED]" {
+		t.Errorf("Unexpected spans %+v", spans)
+	}
+}
@@ -0,0 +1,31 @@
+package redact
+
+import (
+	"regexp"
+)
+
+// Common rules. They are meant as a starting point; copy one and change its
+// Action to hash or drop rather than mask.
+var (
+	// Secrets masks values stored under keys that usually hold credentials.
+	Secrets = Rule{
+		Name:   "secrets",
+		Key:    regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api[-_.]?key|authorization|cookie)`),
+		Action: Mask,
+	}
+
+	// Emails hashes email addresses wherever they appear.
+	Emails = Rule{
+		Name:   "emails",
+		Value:  regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		Action: Hash,
+	}
+
+	// CardNumbers masks sequences of 13 to 19 digits, optionally separated by
+	// spaces or dashes, that look like payment card numbers.
+	CardNumbers = Rule{
+		Name:   "card-numbers",
+		Value:  regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Action: Mask,
+	}
+)
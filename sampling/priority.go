@@ -0,0 +1,64 @@
+package sampling
+
+import (
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// PriorityOverride looks for an `ext.SamplingPriority` entry in `tags`. If
+// one is found and has a numeric value, PriorityOverride returns the decision
+// it implies (see Priority) and true.
+func PriorityOverride(tags opentracing.Tags) (Decision, bool) {
+	value, ok := tags[string(ext.SamplingPriority)]
+	if !ok {
+		return Decision{}, false
+	}
+	sampled, ok := Priority(value)
+	if !ok {
+		return Decision{}, false
+	}
+	return Decision{
+		Sampled: sampled,
+		Tags: opentracing.Tags{
+			SamplerTypeTagKey:  "priority",
+			SamplerParamTagKey: value,
+		},
+	}, true
+}
+
+// Priority interprets the value of an `ext.SamplingPriority` tag: a
+// priority greater than zero forces the trace to be sampled, and a priority
+// of zero forces it not to be. The second return value is false if `value`
+// is not numeric.
+//
+// Tracers that support changing the decision after a Span has started should
+// call Priority from Span.SetTag().
+func Priority(value interface{}) (sampled bool, ok bool) {
+	switch v := value.(type) {
+	case uint16:
+		return v > 0, true
+	case uint8:
+		return v > 0, true
+	case uint32:
+		return v > 0, true
+	case uint64:
+		return v > 0, true
+	case uint:
+		return v > 0, true
+	case int:
+		return v > 0, true
+	case int8:
+		return v > 0, true
+	case int16:
+		return v > 0, true
+	case int32:
+		return v > 0, true
+	case int64:
+		return v > 0, true
+	case float32:
+		return v > 0, true
+	case float64:
+		return v > 0, true
+	}
+	return false, false
+}
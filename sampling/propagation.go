@@ -0,0 +1,67 @@
+package sampling
+
+import (
+	"strconv"
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// SampledTextMapKey is the TextMap key used by InjectDecision and
+// JoinDecision.
+const SampledTextMapKey = "ot-tracer-sampled"
+
+// InjectDecision encodes `d` in `carrier` so that a downstream Tracer can
+// recover it with JoinDecision. Tracers should call it from their own
+// Tracer.Inject() implementation.
+//
+// Only the TextMap format is supported; the Binary format is specific to
+// each Tracer, which should encode Decision.Sampled itself.
+func InjectDecision(d Decision, format interface{}, carrier interface{}) error {
+	if format != opentracing.TextMap {
+		return opentracing.ErrUnsupportedFormat
+	}
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+	writer.Set(SampledTextMapKey, strconv.FormatBool(d.Sampled))
+	return nil
+}
+
+// JoinDecision decodes a Decision previously encoded by InjectDecision.
+//
+// Errors follow the Tracer.Join() conventions: ErrTraceNotFound if the
+// carrier holds no decision, ErrTraceCorrupted if it cannot be parsed,
+// ErrInvalidCarrier for a carrier of the wrong type and ErrUnsupportedFormat
+// for any format but TextMap.
+func JoinDecision(format interface{}, carrier interface{}) (Decision, error) {
+	if format != opentracing.TextMap {
+		return Decision{}, opentracing.ErrUnsupportedFormat
+	}
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return Decision{}, opentracing.ErrInvalidCarrier
+	}
+	var d Decision
+	found := false
+	err := reader.ForeachKey(func(key, val string) error {
+		if strings.ToLower(key) != SampledTextMapKey {
+			return nil
+		}
+		sampled, err := strconv.ParseBool(val)
+		if err != nil {
+			return opentracing.ErrTraceCorrupted
+		}
+		d.Sampled = sampled
+		found = true
+		return nil
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	if !found {
+		return Decision{}, opentracing.ErrTraceNotFound
+	}
+	return d, nil
+}
@@ -0,0 +1,219 @@
+// Package sampling provides pluggable sampling decisions for Tracer
+// implementations, along with the means to propagate a decision through
+// Tracer.Inject() and Tracer.Join() so that downstream services agree with
+// it.
+package sampling
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Params holds the information available to a Sampler at the start of a
+// Span.
+type Params struct {
+	// OperationName is the operation name the Span was started with.
+	OperationName string
+
+	// Tags are the tags the Span was started with (per
+	// StartSpanOptions.Tags). May be nil.
+	Tags opentracing.Tags
+
+	// TraceID is the (low 64 bits of the) trace ID of the new Span, or zero
+	// if the Tracer does not assign numeric trace IDs.
+	TraceID uint64
+
+	// Parent is the decision made for the parent Span, whether local or
+	// decoded by Tracer.Join(); nil if the Span is a trace root.
+	Parent *Decision
+}
+
+// Decision is the outcome of a Sampler.
+type Decision struct {
+	// Sampled is true if the trace should be recorded.
+	Sampled bool
+
+	// Tags optionally describe how the decision was made (e.g.,
+	// "sampler.type"). Tracers may add them to the Span.
+	Tags opentracing.Tags
+}
+
+// Sampler decides whether a trace should be recorded.
+//
+// Samplers are consulted once per Span at start time and must be safe for
+// concurrent use.
+type Sampler interface {
+	ShouldSample(params Params) Decision
+}
+
+// SamplerFunc adapts an ordinary function to the Sampler interface.
+type SamplerFunc func(params Params) Decision
+
+// ShouldSample belongs to the Sampler interface.
+func (f SamplerFunc) ShouldSample(params Params) Decision {
+	return f(params)
+}
+
+// Tag keys set in Decision.Tags by the builtin Samplers.
+const (
+	SamplerTypeTagKey  = "sampler.type"
+	SamplerParamTagKey = "sampler.param"
+)
+
+// Const returns a Sampler that always returns the same decision.
+func Const(sampled bool) Sampler {
+	return constSampler{decision: Decision{
+		Sampled: sampled,
+		Tags: opentracing.Tags{
+			SamplerTypeTagKey:  "const",
+			SamplerParamTagKey: sampled,
+		},
+	}}
+}
+
+type constSampler struct {
+	decision Decision
+}
+
+func (s constSampler) ShouldSample(params Params) Decision {
+	return s.decision
+}
+
+// NewProbabilistic returns a Sampler that samples a `rate` fraction of
+// traces, with 0 <= rate <= 1. A NaN rate never samples.
+//
+// When Params.TraceID is non-zero the decision is a deterministic function of
+// it, so every service using the same rate makes the same decision for a
+// given trace.
+func NewProbabilistic(rate float64) Sampler {
+	if !(rate > 0) {
+		rate = 0
+	}
+	rate = math.Min(1, rate)
+	return &probabilisticSampler{
+		rate:      rate,
+		threshold: uint64(rate * math.MaxUint64),
+		tags: opentracing.Tags{
+			SamplerTypeTagKey:  "probabilistic",
+			SamplerParamTagKey: rate,
+		},
+	}
+}
+
+type probabilisticSampler struct {
+	rate      float64
+	threshold uint64
+	tags      opentracing.Tags
+}
+
+func (s *probabilisticSampler) ShouldSample(params Params) Decision {
+	var sampled bool
+	switch {
+	case s.rate >= 1:
+		sampled = true
+	case params.TraceID != 0:
+		sampled = params.TraceID < s.threshold
+	default:
+		sampled = rand.Float64() < s.rate
+	}
+	return Decision{Sampled: sampled, Tags: s.tags}
+}
+
+// NewRateLimiting returns a Sampler that samples at most `maxPerSecond`
+// traces per second, using a token bucket that holds up to one second's
+// worth of credit. If maxPerSecond is not positive (or is NaN), it never
+// samples.
+func NewRateLimiting(maxPerSecond float64) Sampler {
+	balance := math.Max(maxPerSecond, 1)
+	if !(maxPerSecond > 0) {
+		maxPerSecond, balance = 0, 0
+	}
+	return &rateLimitingSampler{
+		maxPerSecond: maxPerSecond,
+		balance:      balance,
+		maxBalance:   math.Max(maxPerSecond, 1),
+		lastTick:     time.Now(),
+		now:          time.Now,
+		tags: opentracing.Tags{
+			SamplerTypeTagKey:  "ratelimiting",
+			SamplerParamTagKey: maxPerSecond,
+		},
+	}
+}
+
+type rateLimitingSampler struct {
+	maxPerSecond float64
+	maxBalance   float64
+	tags         opentracing.Tags
+	now          func() time.Time
+
+	lock     sync.Mutex
+	balance  float64
+	lastTick time.Time
+}
+
+func (s *rateLimitingSampler) ShouldSample(params Params) Decision {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	now := s.now()
+	s.balance += now.Sub(s.lastTick).Seconds() * s.maxPerSecond
+	s.lastTick = now
+	if s.balance > s.maxBalance {
+		s.balance = s.maxBalance
+	}
+	sampled := s.balance >= 1
+	if sampled {
+		s.balance--
+	}
+	return Decision{Sampled: sampled, Tags: s.tags}
+}
+
+// NewPerOperation returns a Sampler that defers to `operations` by
+// Params.OperationName, and to `fallback` for operation names not found
+// there.
+func NewPerOperation(operations map[string]Sampler, fallback Sampler) Sampler {
+	ops := make(map[string]Sampler, len(operations))
+	for k, v := range operations {
+		ops[k] = v
+	}
+	return &perOperationSampler{operations: ops, fallback: fallback}
+}
+
+type perOperationSampler struct {
+	operations map[string]Sampler
+	fallback   Sampler
+}
+
+func (s *perOperationSampler) ShouldSample(params Params) Decision {
+	if sampler, ok := s.operations[params.OperationName]; ok {
+		return sampler.ShouldSample(params)
+	}
+	return s.fallback.ShouldSample(params)
+}
+
+// ParentBased returns a Sampler that reuses Params.Parent when it is set and
+// defers to `root` for new traces. Most Tracers should wrap their Sampler
+// this way so that every Span in a trace shares one decision.
+func ParentBased(root Sampler) Sampler {
+	return SamplerFunc(func(params Params) Decision {
+		if params.Parent != nil {
+			return Decision{Sampled: params.Parent.Sampled}
+		}
+		return root.ShouldSample(params)
+	})
+}
+
+// WithPriority returns a Sampler that honours an `ext.SamplingPriority` tag
+// in Params.Tags (see PriorityOverride) and otherwise defers to `s`.
+func WithPriority(s Sampler) Sampler {
+	return SamplerFunc(func(params Params) Decision {
+		if d, ok := PriorityOverride(params.Tags); ok {
+			return d
+		}
+		return s.ShouldSample(params)
+	})
+}
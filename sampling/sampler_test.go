@@ -0,0 +1,134 @@
+package sampling
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+func TestConstSampler(t *testing.T) {
+	if !Const(true).ShouldSample(Params{}).Sampled {
+		t.Error("Const(true) did not sample")
+	}
+	if Const(false).ShouldSample(Params{}).Sampled {
+		t.Error("Const(false) sampled")
+	}
+}
+
+func TestProbabilisticSampler(t *testing.T) {
+	s := NewProbabilistic(0.5)
+	if !s.ShouldSample(Params{TraceID: 1}).Sampled {
+		t.Error("Expected low trace ID to be sampled")
+	}
+	if s.ShouldSample(Params{TraceID: math.MaxUint64}).Sampled {
+		t.Error("Expected high trace ID not to be sampled")
+	}
+	if !NewProbabilistic(1).ShouldSample(Params{TraceID: math.MaxUint64}).Sampled {
+		t.Error("Expected rate 1 to always sample")
+	}
+	for _, rate := range []float64{0, math.NaN()} {
+		s := NewProbabilistic(rate)
+		if s.ShouldSample(Params{}).Sampled || s.ShouldSample(Params{TraceID: 1}).Sampled {
+			t.Errorf("Expected rate %v never to sample", rate)
+		}
+	}
+}
+
+func TestRateLimitingSampler(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := NewRateLimiting(2).(*rateLimitingSampler)
+	s.now = func() time.Time { return now }
+	s.lastTick = now
+
+	sampled := 0
+	for i := 0; i < 5; i++ {
+		if s.ShouldSample(Params{}).Sampled {
+			sampled++
+		}
+	}
+	if sampled != 2 {
+		t.Errorf("Sampled %v traces, expected 2", sampled)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if !s.ShouldSample(Params{}).Sampled {
+		t.Error("Expected a token to be available after 500ms")
+	}
+	if s.ShouldSample(Params{}).Sampled {
+		t.Error("Expected the bucket to be empty")
+	}
+
+	for _, rate := range []float64{0, -1, math.NaN()} {
+		s := NewRateLimiting(rate).(*rateLimitingSampler)
+		s.now = func() time.Time { return now }
+		if s.ShouldSample(Params{}).Sampled {
+			t.Errorf("Expected rate %v never to sample", rate)
+		}
+		now = now.Add(time.Hour)
+		if s.ShouldSample(Params{}).Sampled {
+			t.Errorf("Expected rate %v never to sample", rate)
+		}
+	}
+}
+
+func TestPerOperationSampler(t *testing.T) {
+	s := NewPerOperation(map[string]Sampler{"health": Const(false)}, Const(true))
+	if s.ShouldSample(Params{OperationName: "health"}).Sampled {
+		t.Error("Expected health checks not to be sampled")
+	}
+	if !s.ShouldSample(Params{OperationName: "GetFeed"}).Sampled {
+		t.Error("Expected fallback sampler to be used")
+	}
+}
+
+func TestParentBasedAndPriority(t *testing.T) {
+	s := WithPriority(ParentBased(Const(false)))
+	if !s.ShouldSample(Params{Parent: &Decision{Sampled: true}}).Sampled {
+		t.Error("Expected the parent decision to be honoured")
+	}
+	if s.ShouldSample(Params{}).Sampled {
+		t.Error("Expected the root sampler to be used")
+	}
+	tags := opentracing.Tags{string(ext.SamplingPriority): uint16(1)}
+	if !s.ShouldSample(Params{Tags: tags}).Sampled {
+		t.Error("Expected sampling.priority=1 to force sampling")
+	}
+	tags = opentracing.Tags{string(ext.SamplingPriority): 0}
+	s = WithPriority(Const(true))
+	if s.ShouldSample(Params{Tags: tags}).Sampled {
+		t.Error("Expected sampling.priority=0 to disable sampling")
+	}
+}
+
+func TestDecisionPropagation(t *testing.T) {
+	h := http.Header{}
+	carrier := opentracing.HTTPHeaderTextMapCarrier(h)
+	if err := InjectDecision(Decision{Sampled: true}, opentracing.TextMap, carrier); err != nil {
+		t.Fatal(err)
+	}
+	d, err := JoinDecision(opentracing.TextMap, carrier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Sampled {
+		t.Error("Expected the decision to survive propagation")
+	}
+
+	empty := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+	if _, err := JoinDecision(opentracing.TextMap, empty); err != opentracing.ErrTraceNotFound {
+		t.Errorf("Expected ErrTraceNotFound, got %v", err)
+	}
+	h.Set(SampledTextMapKey, "maybe")
+	if _, err := JoinDecision(opentracing.TextMap, carrier); err != opentracing.ErrTraceCorrupted {
+		t.Errorf("Expected ErrTraceCorrupted, got %v", err)
+	}
+	if _, err := JoinDecision(opentracing.TextMap, "nope"); err != opentracing.ErrInvalidCarrier {
+		t.Errorf("Expected ErrInvalidCarrier, got %v", err)
+	}
+	if err := InjectDecision(Decision{}, opentracing.Binary, carrier); err != opentracing.ErrUnsupportedFormat {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
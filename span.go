@@ -65,7 +65,9 @@ type Span interface {
 	//
 	// IMPORTANT NOTE #2: Use this thoughtfully and with care. Every key and
 	// value is copied into every local *and remote* child of this Span, and
-	// that can add up to a lot of network and cpu overhead.
+	// that can add up to a lot of network and cpu overhead. Tracers may bound
+	// this overhead with a BaggagePolicy, in which case items that violate the
+	// policy are dropped.
 	//
 	// IMPORTANT NOTE #3: Baggage item keys have a restricted format:
 	// implementations may wish to use them as HTTP header keys (or key
@@ -91,6 +93,34 @@ type Span interface {
 	Tracer() Tracer
 }
 
+// RecordingSpan is an optional interface for Span implementations that know
+// whether the data set on them is going to be recorded (e.g. because the
+// trace was sampled out, or the Span is a no-op).
+type RecordingSpan interface {
+	// IsRecording returns false if tags, logs and other data set on the
+	// Span will be discarded.
+	IsRecording() bool
+}
+
+// IsRecording returns false if `sp` is nil or a RecordingSpan that is not
+// recording, and true otherwise. Instrumentation can use it to skip
+// computing expensive tags and log payloads:
+//
+//	if opentracing.IsRecording(span) {
+//	    span.SetTag("request.body", dump(req))
+//	}
+//
+// IsRecording never allocates.
+func IsRecording(sp Span) bool {
+	if sp == nil {
+		return false
+	}
+	if rs, ok := sp.(RecordingSpan); ok {
+		return rs.IsRecording()
+	}
+	return true
+}
+
 // LogData is data associated to a Span. Every LogData instance should specify
 // at least one of Event and/or Payload.
 type LogData struct {
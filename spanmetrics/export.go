@@ -0,0 +1,69 @@
+package spanmetrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus writes the Series of `src` to `w` in the Prometheus text
+// exposition format, as three metric families named `<prefix>_requests_total`,
+// `<prefix>_errors_total` and `<prefix>_duration_seconds` (a histogram).
+func WritePrometheus(w io.Writer, src Source, prefix string) error {
+	series := src.Snapshot()
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "# HELP %s_requests_total Number of finished spans.\n", prefix)
+	fmt.Fprintf(bw, "# TYPE %s_requests_total counter\n", prefix)
+	for _, s := range series {
+		fmt.Fprintf(bw, "%s_requests_total{%s} %d\n", prefix, labels(s.Key), s.Requests)
+	}
+
+	fmt.Fprintf(bw, "# HELP %s_errors_total Number of finished spans that failed.\n", prefix)
+	fmt.Fprintf(bw, "# TYPE %s_errors_total counter\n", prefix)
+	for _, s := range series {
+		fmt.Fprintf(bw, "%s_errors_total{%s} %d\n", prefix, labels(s.Key), s.Errors)
+	}
+
+	fmt.Fprintf(bw, "# HELP %s_duration_seconds Duration of finished spans.\n", prefix)
+	fmt.Fprintf(bw, "# TYPE %s_duration_seconds histogram\n", prefix)
+	for _, s := range series {
+		l := labels(s.Key)
+		var cumulative uint64
+		for i, bound := range s.Latency.Bounds {
+			cumulative += s.Latency.Counts[i]
+			fmt.Fprintf(bw, "%s_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				prefix, l, strconv.FormatFloat(bound.Seconds(), 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(bw, "%s_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", prefix, l, s.Latency.Count)
+		fmt.Fprintf(bw, "%s_duration_seconds_sum{%s} %s\n",
+			prefix, l, strconv.FormatFloat(s.Latency.Sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(bw, "%s_duration_seconds_count{%s} %d\n", prefix, l, s.Latency.Count)
+	}
+	return bw.Flush()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(k Key) string {
+	status := ""
+	if k.StatusCode != 0 {
+		status = strconv.Itoa(k.StatusCode)
+	}
+	return fmt.Sprintf(`operation="%s",span_kind="%s",component="%s",http_status_code="%s"`,
+		labelEscaper.Replace(k.Operation),
+		labelEscaper.Replace(k.Kind),
+		labelEscaper.Replace(k.Component),
+		status)
+}
+
+// Expvar returns an expvar.Var whose JSON value is the current Snapshot of
+// `src`. Publish it with e.g. expvar.Publish("spans", spanmetrics.Expvar(agg)).
+func Expvar(src Source) expvar.Var {
+	return expvar.Func(func() interface{} {
+		return src.Snapshot()
+	})
+}
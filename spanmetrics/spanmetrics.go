@@ -0,0 +1,254 @@
+// Package spanmetrics derives RED (rate, errors, duration) metrics from
+// finished spans, so that services get request counts, error counts and
+// latency histograms without separate metrics instrumentation.
+//
+// An Aggregator is a processor.SpanProcessor (and a recorder.SpanRecorder);
+// it groups spans by operation name, span.kind, component and HTTP status
+// code. Since it only sees the spans that reach it, it should be installed
+// before any sampling or filtering stage to count all requests.
+//
+// The aggregated Series are exposed through the Source interface, which
+// WritePrometheus and Expvar bridge to the Prometheus text format and to
+// the expvar package respectively.
+package spanmetrics
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// DefaultBuckets are the default latency histogram bucket upper bounds.
+var DefaultBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// OverflowOperation is the operation name of the Series that aggregates the
+// spans that would create more than Options.MaxSeries series. Once there is
+// an overflow Series, spans of an operation actually named "other" with no
+// other tags are aggregated in it too.
+const OverflowOperation = "other"
+
+// Key identifies a Series. Fields are empty (or zero) when the span did not
+// carry the corresponding tag.
+type Key struct {
+	Operation  string
+	Kind       string
+	Component  string
+	StatusCode int
+}
+
+// Histogram counts latency observations per bucket.
+type Histogram struct {
+	// Bounds are the bucket upper bounds, in increasing order.
+	Bounds []time.Duration
+
+	// Counts holds the number of observations in each bucket (not
+	// cumulative); the extra last element counts the observations above
+	// the last bound.
+	Counts []uint64
+
+	Sum   time.Duration
+	Count uint64
+}
+
+// Series holds the metrics of the spans sharing a Key.
+type Series struct {
+	Key
+
+	Requests uint64
+	Errors   uint64
+	Latency  Histogram
+}
+
+// Source provides a consistent snapshot of aggregated Series.
+type Source interface {
+	Snapshot() []Series
+}
+
+// Options configures an Aggregator.
+type Options struct {
+	// Buckets are the latency histogram bounds. Defaults to DefaultBuckets.
+	Buckets []time.Duration
+
+	// MaxSeries bounds the number of Series, to protect against high
+	// cardinality operation names. Once MaxSeries-1 Series exist, spans
+	// with new keys are aggregated in a single Series whose Key only has
+	// its Operation set, to OverflowOperation. Zero means no limit.
+	MaxSeries int
+
+	// IsError decides whether a span counts as an error. Defaults to
+	// IsError.
+	IsError func(span *recorder.RawSpan) bool
+}
+
+// IsError returns true if `span` is tagged with ext.Error=true or with a
+// 5xx ext.HTTPStatusCode.
+func IsError(span *recorder.RawSpan) bool {
+	if isErr, ok := span.Tags[string(ext.Error)].(bool); ok && isErr {
+		return true
+	}
+	return statusCode(span.Tags[string(ext.HTTPStatusCode)]) >= 500
+}
+
+// Aggregator aggregates finished spans into Series.
+type Aggregator struct {
+	options Options
+
+	lock   sync.Mutex
+	series map[Key]*Series
+}
+
+// New returns an empty Aggregator configured by `opts`.
+func New(opts Options) *Aggregator {
+	if opts.Buckets == nil {
+		opts.Buckets = DefaultBuckets
+	}
+	opts.Buckets = append([]time.Duration(nil), opts.Buckets...)
+	if opts.IsError == nil {
+		opts.IsError = IsError
+	}
+	return &Aggregator{options: opts, series: make(map[Key]*Series)}
+}
+
+// OnStart belongs to the processor.SpanProcessor interface.
+func (a *Aggregator) OnStart(sp basictracer.Span) {}
+
+// OnFinish belongs to the processor.SpanProcessor interface. It aggregates
+// `span` and never drops it.
+func (a *Aggregator) OnFinish(span *recorder.RawSpan) bool {
+	a.observe(span)
+	return true
+}
+
+// RecordSpan belongs to the recorder.SpanRecorder interface.
+func (a *Aggregator) RecordSpan(span recorder.RawSpan) {
+	a.observe(&span)
+}
+
+func (a *Aggregator) observe(span *recorder.RawSpan) {
+	key := Key{
+		Operation:  span.Operation,
+		Kind:       stringValue(span.Tags[string(ext.SpanKind)]),
+		Component:  stringValue(span.Tags[string(ext.Component)]),
+		StatusCode: statusCode(span.Tags[string(ext.HTTPStatusCode)]),
+	}
+	isErr := a.options.IsError(span)
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	s, ok := a.series[key]
+	if !ok {
+		if a.options.MaxSeries > 0 {
+			overflow := Key{Operation: OverflowOperation}
+			n := len(a.series)
+			if _, ok := a.series[overflow]; ok {
+				n--
+			}
+			// Keep the last Series for the overflow.
+			if n >= a.options.MaxSeries-1 {
+				key = overflow
+				s = a.series[key]
+			}
+		}
+		if s == nil {
+			s = &Series{
+				Key: key,
+				Latency: Histogram{
+					Bounds: a.options.Buckets,
+					Counts: make([]uint64, len(a.options.Buckets)+1),
+				},
+			}
+			a.series[key] = s
+		}
+	}
+	s.Requests++
+	if isErr {
+		s.Errors++
+	}
+	bucket := sort.Search(len(s.Latency.Bounds), func(i int) bool {
+		return span.Duration <= s.Latency.Bounds[i]
+	})
+	s.Latency.Counts[bucket]++
+	s.Latency.Sum += span.Duration
+	s.Latency.Count++
+}
+
+// Snapshot belongs to the Source interface. Series are sorted by Key.
+func (a *Aggregator) Snapshot() []Series {
+	a.lock.Lock()
+	rval := make([]Series, 0, len(a.series))
+	for _, s := range a.series {
+		c := *s
+		c.Latency.Bounds = append([]time.Duration(nil), s.Latency.Bounds...)
+		c.Latency.Counts = append([]uint64(nil), s.Latency.Counts...)
+		rval = append(rval, c)
+	}
+	a.lock.Unlock()
+
+	sort.Sort(seriesByKey(rval))
+	return rval
+}
+
+type seriesByKey []Series
+
+func (s seriesByKey) Len() int      { return len(s) }
+func (s seriesByKey) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s seriesByKey) Less(i, j int) bool {
+	a, b := s[i].Key, s[j].Key
+	switch {
+	case a.Operation != b.Operation:
+		return a.Operation < b.Operation
+	case a.Kind != b.Kind:
+		return a.Kind < b.Kind
+	case a.Component != b.Component:
+		return a.Component < b.Component
+	}
+	return a.StatusCode < b.StatusCode
+}
+
+// Reset discards all Series.
+func (a *Aggregator) Reset() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.series = make(map[Key]*Series)
+}
+
+func stringValue(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case ext.SpanKindEnum:
+		return string(s)
+	}
+	return ""
+}
+
+// statusCode converts an integer tag value of any type to an int, or
+// returns 0.
+func statusCode(v interface{}) int {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(rv.Uint())
+	}
+	return 0
+}
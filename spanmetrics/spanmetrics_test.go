@@ -0,0 +1,117 @@
+package spanmetrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func span(op string, d time.Duration, tags opentracing.Tags) recorder.RawSpan {
+	return recorder.RawSpan{Operation: op, Duration: d, Tags: tags}
+}
+
+func TestAggregator(t *testing.T) {
+	agg := New(Options{Buckets: []time.Duration{10 * time.Millisecond, 100 * time.Millisecond}})
+	server := opentracing.Tags{
+		string(ext.SpanKind):       ext.SpanKindRPCServer,
+		string(ext.Component):      "net/http",
+		string(ext.HTTPStatusCode): uint16(200),
+	}
+	agg.RecordSpan(span("GET /feed", 5*time.Millisecond, server))
+	agg.RecordSpan(span("GET /feed", 50*time.Millisecond, server))
+	agg.RecordSpan(span("GET /feed", time.Second, server))
+	agg.RecordSpan(span("GET /feed", time.Millisecond, opentracing.Tags{
+		string(ext.SpanKind):       ext.SpanKindRPCServer,
+		string(ext.Component):      "net/http",
+		string(ext.HTTPStatusCode): 503,
+	}))
+	agg.RecordSpan(span("query", time.Millisecond, opentracing.Tags{string(ext.Error): true}))
+
+	series := agg.Snapshot()
+	if len(series) != 3 {
+		t.Fatalf("Unexpected series %+v", series)
+	}
+	ok, failed, query := series[0], series[1], series[2]
+	if ok.Key != (Key{"GET /feed", "server", "net/http", 200}) || ok.Requests != 3 || ok.Errors != 0 {
+		t.Errorf("Unexpected series %+v", ok)
+	}
+	if counts := ok.Latency.Counts; len(counts) != 3 || counts[0] != 1 || counts[1] != 1 || counts[2] != 1 {
+		t.Errorf("Unexpected histogram %+v", ok.Latency)
+	}
+	if ok.Latency.Sum != 1055*time.Millisecond || ok.Latency.Count != 3 {
+		t.Errorf("Unexpected histogram %+v", ok.Latency)
+	}
+	if failed.StatusCode != 503 || failed.Errors != 1 {
+		t.Errorf("Unexpected series %+v", failed)
+	}
+	if query.Key != (Key{Operation: "query"}) || query.Errors != 1 {
+		t.Errorf("Unexpected series %+v", query)
+	}
+
+	agg.Reset()
+	if len(agg.Snapshot()) != 0 {
+		t.Error("Reset did not discard series")
+	}
+}
+
+func TestMaxSeries(t *testing.T) {
+	agg := New(Options{MaxSeries: 3})
+	for _, op := range []string{"a", "b", "c", "d", "a"} {
+		agg.OnFinish(&recorder.RawSpan{Operation: op})
+	}
+	for i := 0; i < 10; i++ {
+		agg.OnFinish(&recorder.RawSpan{Operation: "e", Tags: opentracing.Tags{string(ext.HTTPStatusCode): 200 + i}})
+	}
+	series := agg.Snapshot()
+	if len(series) != 3 || series[0].Requests != 2 || series[2].Key != (Key{Operation: OverflowOperation}) || series[2].Requests != 12 {
+		t.Errorf("Unexpected series %+v", series)
+	}
+
+	series[0].Latency.Bounds[0] = 0
+	if DefaultBuckets[0] != time.Millisecond || agg.Snapshot()[0].Latency.Bounds[0] != time.Millisecond {
+		t.Error("Snapshot shares its Bounds")
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	agg := New(Options{Buckets: []time.Duration{10 * time.Millisecond, 500 * time.Millisecond}})
+	agg.RecordSpan(span(`say "hi"`, 20*time.Millisecond, opentracing.Tags{string(ext.HTTPStatusCode): 500}))
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, agg, "spans"); err != nil {
+		t.Fatal(err)
+	}
+	labels := `operation="say \"hi\"",span_kind="",component="",http_status_code="500"`
+	for _, line := range []string{
+		"# TYPE spans_requests_total counter",
+		"spans_requests_total{" + labels + "} 1",
+		"spans_errors_total{" + labels + "} 1",
+		"# TYPE spans_duration_seconds histogram",
+		"spans_duration_seconds_bucket{" + labels + `,le="0.01"} 0`,
+		"spans_duration_seconds_bucket{" + labels + `,le="0.5"} 1`,
+		"spans_duration_seconds_bucket{" + labels + `,le="+Inf"} 1`,
+		"spans_duration_seconds_sum{" + labels + "} 0.02",
+		"spans_duration_seconds_count{" + labels + "} 1",
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("Missing line %q in:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestExpvar(t *testing.T) {
+	agg := New(Options{})
+	agg.RecordSpan(span("op", time.Millisecond, nil))
+	var decoded []Series
+	if err := json.Unmarshal([]byte(Expvar(agg).String()), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0].Operation != "op" || decoded[0].Requests != 1 {
+		t.Errorf("Unexpected expvar value %+v", decoded)
+	}
+}
@@ -0,0 +1,66 @@
+package tailsampling
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Policy inspects the buffered spans of a trace once its local root has
+// finished and returns true if the trace should be kept.
+//
+// `trace` holds the spans in the order they finished, so the local root is
+// last.
+type Policy func(trace []recorder.RawSpan) bool
+
+// LatencyAbove keeps traces that contain a span whose duration is at least
+// `threshold`.
+func LatencyAbove(threshold time.Duration) Policy {
+	return func(trace []recorder.RawSpan) bool {
+		for _, span := range trace {
+			if span.Duration >= threshold {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// HasError keeps traces that contain a span tagged with `ext.Error` set to
+// true.
+func HasError() Policy {
+	return HasTag(string(ext.Error), true)
+}
+
+// HasTag keeps traces that contain a span with tag `key` set to `value`. A
+// nil `value` matches any value.
+func HasTag(key string, value interface{}) Policy {
+	return func(trace []recorder.RawSpan) bool {
+		for _, span := range trace {
+			v, ok := span.Tags[key]
+			if ok && (value == nil || reflect.DeepEqual(v, value)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// OperationNames keeps traces that contain a span with one of the given
+// operation names.
+func OperationNames(names ...string) Policy {
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+	return func(trace []recorder.RawSpan) bool {
+		for _, span := range trace {
+			if _, ok := set[span.Operation]; ok {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,206 @@
+// Package tailsampling provides a SpanRecorder that makes sampling decisions
+// once a trace has finished, so that slow and failed requests can be kept
+// while the bulk of the traffic is dropped.
+package tailsampling
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+// Options configures a Recorder.
+type Options struct {
+	// Policies decide which traces are kept: a trace is forwarded if any
+	// Policy returns true, and dropped otherwise.
+	Policies []Policy
+
+	// MaxTraces bounds the number of traces buffered at once. When a span
+	// for a new trace arrives and the buffer is full, the oldest buffered
+	// trace is evicted (i.e., dropped). Defaults to 1000.
+	MaxTraces int
+
+	// MaxSpansPerTrace bounds the number of spans buffered for one trace;
+	// further spans for that trace are dropped, except for its local root,
+	// which replaces the most recently buffered span. Defaults to 1000.
+	MaxSpansPerTrace int
+
+	// MaxDecisions bounds the number of past decisions remembered so that
+	// spans which finish after their local root follow the decision made
+	// for their trace. Defaults to MaxTraces.
+	MaxDecisions int
+}
+
+// Metrics holds counters describing the activity of a Recorder.
+type Metrics struct {
+	TracesKept    int64
+	TracesDropped int64
+	// TracesEvicted counts buffered traces dropped to make room for new
+	// ones before their local root finished.
+	TracesEvicted int64
+
+	SpansKept    int64
+	SpansDropped int64
+	// SpansEvicted counts spans dropped as part of an evicted trace.
+	SpansEvicted int64
+	// SpansTruncated counts spans dropped because their trace already held
+	// MaxSpansPerTrace spans. They are also counted in SpansDropped.
+	SpansTruncated int64
+
+	// BufferedTraces and BufferedSpans describe the current buffer size.
+	BufferedTraces int
+	BufferedSpans  int
+}
+
+// Recorder is a SpanRecorder that buffers the spans of each trace until its
+// local root finishes, and then forwards either all of them or none of them
+// to another SpanRecorder based on a set of Policies.
+//
+// A Recorder should be paired with a Tracer that samples every trace (the
+// head sampling decision is ignored).
+type Recorder struct {
+	next    recorder.SpanRecorder
+	options Options
+
+	lock      sync.Mutex
+	traces    map[recorder.TraceID]*list.Element // of *pendingTrace
+	order     *list.List
+	decisions map[recorder.TraceID]bool
+	decided   *list.List // of recorder.TraceID, oldest first
+	metrics   Metrics
+}
+
+type pendingTrace struct {
+	id    recorder.TraceID
+	spans []recorder.RawSpan
+}
+
+// New returns a Recorder that forwards kept traces to `next`.
+func New(next recorder.SpanRecorder, opts Options) *Recorder {
+	if opts.MaxTraces <= 0 {
+		opts.MaxTraces = 1000
+	}
+	if opts.MaxSpansPerTrace <= 0 {
+		opts.MaxSpansPerTrace = 1000
+	}
+	if opts.MaxDecisions <= 0 {
+		opts.MaxDecisions = opts.MaxTraces
+	}
+	return &Recorder{
+		next:      next,
+		options:   opts,
+		traces:    make(map[recorder.TraceID]*list.Element),
+		order:     list.New(),
+		decisions: make(map[recorder.TraceID]bool),
+		decided:   list.New(),
+	}
+}
+
+// RecordSpan belongs to the SpanRecorder interface.
+func (r *Recorder) RecordSpan(span recorder.RawSpan) {
+	id := span.Context.TraceID
+	r.lock.Lock()
+	if keep, ok := r.decisions[id]; ok {
+		r.countLocked(keep, 1)
+		r.lock.Unlock()
+		if keep {
+			r.next.RecordSpan(span)
+		}
+		return
+	}
+	trace := r.pendingLocked(id)
+	switch {
+	case len(trace.spans) < r.options.MaxSpansPerTrace:
+		trace.spans = append(trace.spans, span)
+		r.metrics.BufferedSpans++
+	case span.LocalRoot:
+		// Policies need the local root, so make room for it.
+		trace.spans[len(trace.spans)-1] = span
+		r.metrics.SpansTruncated++
+		r.metrics.SpansDropped++
+	default:
+		r.metrics.SpansTruncated++
+		r.metrics.SpansDropped++
+	}
+	if !span.LocalRoot {
+		r.lock.Unlock()
+		return
+	}
+	r.removeLocked(id)
+	keep := r.decide(trace.spans)
+	r.rememberLocked(id, keep)
+	r.countLocked(keep, len(trace.spans))
+	if keep {
+		r.metrics.TracesKept++
+	} else {
+		r.metrics.TracesDropped++
+	}
+	r.lock.Unlock()
+
+	if keep {
+		for _, s := range trace.spans {
+			r.next.RecordSpan(s)
+		}
+	}
+}
+
+// Metrics returns a snapshot of the Recorder's counters.
+func (r *Recorder) Metrics() Metrics {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	m := r.metrics
+	m.BufferedTraces = r.order.Len()
+	return m
+}
+
+func (r *Recorder) decide(trace []recorder.RawSpan) bool {
+	for _, policy := range r.options.Policies {
+		if policy(trace) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) pendingLocked(id recorder.TraceID) *pendingTrace {
+	if elem, ok := r.traces[id]; ok {
+		return elem.Value.(*pendingTrace)
+	}
+	for r.order.Len() >= r.options.MaxTraces {
+		evicted := r.order.Front().Value.(*pendingTrace)
+		r.removeLocked(evicted.id)
+		r.metrics.TracesEvicted++
+		r.metrics.SpansEvicted += int64(len(evicted.spans))
+	}
+	trace := &pendingTrace{id: id}
+	r.traces[id] = r.order.PushBack(trace)
+	return trace
+}
+
+func (r *Recorder) removeLocked(id recorder.TraceID) {
+	elem, ok := r.traces[id]
+	if !ok {
+		return
+	}
+	r.metrics.BufferedSpans -= len(elem.Value.(*pendingTrace).spans)
+	r.order.Remove(elem)
+	delete(r.traces, id)
+}
+
+func (r *Recorder) rememberLocked(id recorder.TraceID, keep bool) {
+	r.decisions[id] = keep
+	r.decided.PushBack(id)
+	for r.decided.Len() > r.options.MaxDecisions {
+		oldest := r.decided.Remove(r.decided.Front()).(recorder.TraceID)
+		delete(r.decisions, oldest)
+	}
+}
+
+func (r *Recorder) countLocked(keep bool, spans int) {
+	if keep {
+		r.metrics.SpansKept += int64(spans)
+	} else {
+		r.metrics.SpansDropped += int64(spans)
+	}
+}
@@ -0,0 +1,103 @@
+package tailsampling
+
+import (
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func rawSpan(trace uint64, span uint64, localRoot bool, d time.Duration, tags opentracing.Tags) recorder.RawSpan {
+	return recorder.RawSpan{
+		Context:   recorder.SpanContext{TraceID: recorder.TraceID{Low: trace}, SpanID: span},
+		LocalRoot: localRoot,
+		Operation: "op",
+		Duration:  d,
+		Tags:      tags,
+	}
+}
+
+func TestRecorderKeepsWholeTrace(t *testing.T) {
+	sink := recorder.NewInMemoryRecorder()
+	r := New(sink, Options{Policies: []Policy{HasError(), LatencyAbove(time.Second)}})
+
+	// Trace 1 has a failed child: kept.
+	r.RecordSpan(rawSpan(1, 2, false, time.Millisecond, opentracing.Tags{"error": true}))
+	r.RecordSpan(rawSpan(1, 1, true, time.Millisecond, nil))
+	// Trace 2 is fast and healthy: dropped.
+	r.RecordSpan(rawSpan(2, 4, false, time.Millisecond, nil))
+	r.RecordSpan(rawSpan(2, 3, true, time.Millisecond, nil))
+	// Trace 3 is slow: kept.
+	r.RecordSpan(rawSpan(3, 5, true, 2*time.Second, nil))
+	// A late span of trace 1 follows the earlier decision.
+	r.RecordSpan(rawSpan(1, 6, false, time.Millisecond, nil))
+
+	spans := sink.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("Recorded %v spans, expected 4", len(spans))
+	}
+	for _, s := range spans {
+		if s.Context.TraceID.Low == 2 {
+			t.Errorf("Unexpected span from dropped trace: %+v", s)
+		}
+	}
+	m := r.Metrics()
+	if m.TracesKept != 2 || m.TracesDropped != 1 || m.SpansKept != 4 || m.SpansDropped != 2 {
+		t.Errorf("Unexpected metrics: %+v", m)
+	}
+	if m.BufferedTraces != 0 || m.BufferedSpans != 0 {
+		t.Errorf("Expected an empty buffer: %+v", m)
+	}
+}
+
+func TestRecorderBoundsMemory(t *testing.T) {
+	sink := recorder.NewInMemoryRecorder()
+	r := New(sink, Options{
+		Policies:         []Policy{OperationNames("op")},
+		MaxTraces:        2,
+		MaxSpansPerTrace: 2,
+	})
+	for i := uint64(1); i <= 3; i++ {
+		r.RecordSpan(rawSpan(i, i*10, false, 0, nil))
+	}
+	r.RecordSpan(rawSpan(3, 31, false, 0, nil))
+	r.RecordSpan(rawSpan(3, 32, false, 0, nil))
+
+	m := r.Metrics()
+	if m.TracesEvicted != 1 || m.SpansEvicted != 1 {
+		t.Errorf("Expected one evicted trace: %+v", m)
+	}
+	if m.SpansTruncated != 1 {
+		t.Errorf("Expected one truncated span: %+v", m)
+	}
+	if m.BufferedTraces != 2 || m.BufferedSpans != 3 {
+		t.Errorf("Unexpected buffer size: %+v", m)
+	}
+
+	// Trace 1 was evicted, so its root starts a new (partial) trace.
+	r.RecordSpan(rawSpan(1, 1, true, 0, nil))
+	if n := len(sink.GetSpans()); n != 1 {
+		t.Errorf("Recorded %v spans, expected 1", n)
+	}
+
+	// The root of the full trace 3 replaces a buffered span.
+	r.RecordSpan(rawSpan(3, 3, true, 0, nil))
+	spans := sink.GetSpans()
+	if len(spans) != 3 || !spans[2].LocalRoot {
+		t.Errorf("Expected trace 3 to be recorded with its root: %+v", spans)
+	}
+	if m := r.Metrics(); m.SpansTruncated != 2 || m.SpansDropped != 2 {
+		t.Errorf("Unexpected metrics: %+v", m)
+	}
+}
+
+func TestHasTag(t *testing.T) {
+	trace := []recorder.RawSpan{rawSpan(1, 1, true, 0, opentracing.Tags{"tenant": "acme"})}
+	if !HasTag("tenant", "acme")(trace) || !HasTag("tenant", nil)(trace) {
+		t.Error("Expected tenant tag to match")
+	}
+	if HasTag("tenant", "other")(trace) {
+		t.Error("Expected tenant tag not to match")
+	}
+}
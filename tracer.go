@@ -4,8 +4,8 @@ import "time"
 
 // Tracer is a simple, thin interface for Span creation.
 //
-// A straightforward implementation is available via the
-// `opentracing/basictracer-go` package's `standardtracer.New()'.
+// A straightforward implementation is available via the `basictracer`
+// package's `basictracer.New()`.
 type Tracer interface {
 	// Create, start, and return a new Span with the given `operationName`, all
 	// without specifying a parent Span that can be used to incorporate the
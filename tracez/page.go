@@ -0,0 +1,254 @@
+package tracez
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+type page struct {
+	Buckets []string
+	Summary []summaryRow
+
+	// Detail view, if any.
+	Title  string
+	Active []activeSpan
+	Traces []trace
+}
+
+type summaryRow struct {
+	Operation string
+	Active    int
+	Latency   []int
+	Errors    int
+}
+
+type activeSpan struct {
+	Operation string
+	TraceID   recorder.TraceID
+	SpanID    uint64
+	Start     time.Time
+	Age       time.Duration
+}
+
+type trace struct {
+	TraceID recorder.TraceID
+	Roots   []*node
+}
+
+type node struct {
+	Operation string
+	SpanID    uint64
+	Start     time.Time
+	Duration  time.Duration
+	Tags      []string
+	Logs      []string
+	Selected  bool
+	Children  []*node
+}
+
+func (h *Handler) page(req *http.Request) *page {
+	query := req.URL.Query()
+	opName := query.Get("op")
+	now := time.Now()
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.pruneLocked()
+
+	p := &page{}
+	for i, bound := range h.options.LatencyBounds {
+		if i+1 < len(h.options.LatencyBounds) {
+			p.Buckets = append(p.Buckets, fmt.Sprintf("[%v, %v)", bound, h.options.LatencyBounds[i+1]))
+		} else {
+			p.Buckets = append(p.Buckets, fmt.Sprintf(">= %v", bound))
+		}
+	}
+
+	rows := make(map[string]*summaryRow)
+	row := func(name string) *summaryRow {
+		r, ok := rows[name]
+		if !ok {
+			r = &summaryRow{Operation: name, Latency: make([]int, len(h.options.LatencyBounds))}
+			rows[name] = r
+		}
+		return r
+	}
+	for name, op := range h.operations {
+		r := row(name)
+		for i := range op.latency {
+			r.Latency[i] = len(op.latency[i].spans)
+		}
+		r.Errors = len(op.errors.spans)
+	}
+	for _, sp := range h.active {
+		name := sp.Operation()
+		row(name).Active++
+		if _, ok := query["active"]; ok && (opName == "" || opName == name) {
+			ctx := sp.Context()
+			p.Active = append(p.Active, activeSpan{
+				Operation: name,
+				TraceID:   ctx.TraceID,
+				SpanID:    ctx.SpanID,
+				Start:     sp.Start(),
+				Age:       now.Sub(sp.Start()),
+			})
+		}
+	}
+	for _, r := range rows {
+		p.Summary = append(p.Summary, *r)
+	}
+	sort.Sort(byOperation(p.Summary))
+	sort.Sort(byStart(p.Active))
+
+	if _, ok := query["active"]; ok {
+		p.Title = "Active spans " + opName
+		return p
+	}
+	op := h.operations[opName]
+	if op == nil {
+		return p
+	}
+	var selected []recorder.RawSpan
+	if _, ok := query["errors"]; ok {
+		p.Title = "Error spans of " + opName
+		selected = op.errors.newestFirst()
+	} else if bucket, err := strconv.Atoi(query.Get("bucket")); err == nil && bucket >= 0 && bucket < len(op.latency) {
+		p.Title = fmt.Sprintf("Spans of %v with latency %v", opName, p.Buckets[bucket])
+		selected = op.latency[bucket].newestFirst()
+	}
+	if len(selected) == 0 {
+		return p
+	}
+
+	byTrace := make(map[recorder.TraceID]map[uint64]recorder.RawSpan)
+	for _, op := range h.operations {
+		for _, rings := range [][]ring{op.latency, {op.errors}} {
+			for _, r := range rings {
+				for _, span := range r.spans {
+					spans, ok := byTrace[span.Context.TraceID]
+					if !ok {
+						spans = make(map[uint64]recorder.RawSpan)
+						byTrace[span.Context.TraceID] = spans
+					}
+					spans[span.Context.SpanID] = span
+				}
+			}
+		}
+	}
+	for _, span := range selected {
+		p.Traces = append(p.Traces, buildTrace(span, byTrace[span.Context.TraceID]))
+	}
+	return p
+}
+
+// buildTrace returns the tree of the known spans of the trace of `selected`.
+func buildTrace(selected recorder.RawSpan, spans map[uint64]recorder.RawSpan) trace {
+	nodes := make(map[uint64]*node, len(spans))
+	for id, span := range spans {
+		n := &node{
+			Operation: span.Operation,
+			SpanID:    id,
+			Start:     span.Start,
+			Duration:  span.Duration,
+			Selected:  id == selected.Context.SpanID,
+		}
+		for k, v := range span.Tags {
+			n.Tags = append(n.Tags, fmt.Sprintf("%v=%v", k, v))
+		}
+		sort.Strings(n.Tags)
+		for _, ld := range span.Logs {
+			l := ld.Timestamp.Format(time.RFC3339Nano) + " " + ld.Event
+			if ld.Payload != nil {
+				l += fmt.Sprintf(" %+v", ld.Payload)
+			}
+			n.Logs = append(n.Logs, l)
+		}
+		nodes[id] = n
+	}
+	t := trace{TraceID: selected.Context.TraceID}
+	for id, n := range nodes {
+		if parent, ok := nodes[spans[id].ParentSpanID]; ok && parent != n {
+			parent.Children = append(parent.Children, n)
+		} else {
+			t.Roots = append(t.Roots, n)
+		}
+	}
+	for _, n := range nodes {
+		sortNodes(n.Children)
+	}
+	sortNodes(t.Roots)
+	return t
+}
+
+func sortNodes(nodes []*node) {
+	sort.Sort(nodesByStart(nodes))
+}
+
+type byOperation []summaryRow
+
+func (s byOperation) Len() int           { return len(s) }
+func (s byOperation) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byOperation) Less(i, j int) bool { return s[i].Operation < s[j].Operation }
+
+type byStart []activeSpan
+
+func (s byStart) Len() int           { return len(s) }
+func (s byStart) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byStart) Less(i, j int) bool { return s[i].Start.Before(s[j].Start) }
+
+type nodesByStart []*node
+
+func (s nodesByStart) Len() int      { return len(s) }
+func (s nodesByStart) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s nodesByStart) Less(i, j int) bool {
+	if !s[i].Start.Equal(s[j].Start) {
+		return s[i].Start.Before(s[j].Start)
+	}
+	return s[i].SpanID < s[j].SpanID
+}
+
+var pageTemplate = template.Must(template.New("tracez").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>tracez</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; }
+td, th { border: 1px solid #ccc; padding: 2px 6px; text-align: right; }
+td:first-child { text-align: left; }
+.selected > span { background: #ffd; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>tracez</h1>
+<table>
+<tr><th>Operation</th><th>Active</th>{{range .Buckets}}<th>{{.}}</th>{{end}}<th>Errors</th></tr>
+{{range .Summary}}{{$op := .Operation}}<tr>
+<td>{{$op}}</td>
+<td><a href="?op={{$op}}&amp;active">{{.Active}}</a></td>
+{{range $i, $n := .Latency}}<td><a href="?op={{$op}}&amp;bucket={{$i}}">{{$n}}</a></td>{{end}}
+<td><a href="?op={{$op}}&amp;errors">{{.Errors}}</a></td>
+</tr>
+{{end}}</table>
+{{if .Title}}<h2>{{.Title}}</h2>{{end}}
+{{if .Active}}<table>
+<tr><th>Operation</th><th>Trace</th><th>Span</th><th>Start</th><th>Age</th></tr>
+{{range .Active}}<tr><td>{{.Operation}}</td><td>{{.TraceID}}</td><td>{{printf "%016x" .SpanID}}</td><td>{{.Start.Format "2006-01-02T15:04:05.000000Z07:00"}}</td><td>{{.Age}}</td></tr>
+{{end}}</table>{{end}}
+{{range .Traces}}<h3>trace {{.TraceID}}</h3>
+<ul>{{range .Roots}}{{template "node" .}}{{end}}</ul>
+{{end}}
+</body>
+</html>
+{{define "node"}}<li{{if .Selected}} class="selected"{{end}}><span>{{.Operation}} {{printf "%016x" .SpanID}} {{.Duration}}</span>
+{{if .Tags}}<div>tags: {{range .Tags}}{{.}} {{end}}</div>{{end}}
+{{range .Logs}}<div>log: {{.}}</div>{{end}}
+{{if .Children}}<ul>{{range .Children}}{{template "node" .}}{{end}}</ul>{{end}}
+</li>{{end}}
+`))
@@ -0,0 +1,194 @@
+// Package tracez provides in-process trace debug pages, typically mounted at
+// /debug/tracez, to inspect recent and in-flight spans directly on a running
+// process.
+//
+// A Handler is both a processor.SpanProcessor, which collects the spans, and
+// an http.Handler, which renders them:
+//
+//	tz := tracez.New(tracez.Options{})
+//	pipeline := processor.NewPipeline(exporter, tz)
+//	opts := basictracer.DefaultOptions()
+//	opts.Recorder = pipeline
+//	opts.OnStart = pipeline.OnStart
+//	http.Handle("/debug/tracez", tz)
+//
+// For every operation name the Handler keeps the most recent finished spans
+// in one ring buffer per latency bucket, plus a ring buffer of error spans.
+// Only the spans that reach the Handler are shown, so it should be installed
+// before any stage that drops spans.
+package tracez
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/recorder"
+	"github.com/opentracing/opentracing-go/spanmetrics"
+)
+
+// DefaultLatencyBounds are the default lower bounds of the latency buckets.
+var DefaultLatencyBounds = []time.Duration{
+	0,
+	10 * time.Microsecond,
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+	10 * time.Second,
+	100 * time.Second,
+}
+
+// Options configures a Handler.
+type Options struct {
+	// LatencyBounds are the lower bounds of the latency buckets, in
+	// increasing order starting at zero. Defaults to DefaultLatencyBounds.
+	LatencyBounds []time.Duration
+
+	// SpansPerBucket is the capacity of each ring buffer. Defaults to 10.
+	SpansPerBucket int
+
+	// MaxActiveSpans bounds the number of in-flight spans tracked; spans
+	// started beyond it are not shown as active. Unsampled spans are never
+	// tracked since they do not reach OnFinish, and spans unsampled after
+	// they started (e.g. by setting ext.SamplingPriority to 0) are pruned.
+	// Defaults to 1000.
+	MaxActiveSpans int
+
+	// IsError decides whether a span is shown as an error span. Defaults to
+	// spanmetrics.IsError.
+	IsError func(span *recorder.RawSpan) bool
+}
+
+// Handler collects spans and serves the debug pages.
+type Handler struct {
+	options Options
+
+	lock       sync.Mutex
+	active     map[uint64]basictracer.Span
+	operations map[string]*operation
+}
+
+type operation struct {
+	latency []ring
+	errors  ring
+}
+
+// ring keeps the most recent spans added to it.
+type ring struct {
+	spans []recorder.RawSpan
+	next  int
+}
+
+func (r *ring) add(span recorder.RawSpan, capacity int) {
+	if len(r.spans) < capacity {
+		r.spans = append(r.spans, span)
+		return
+	}
+	r.spans[r.next] = span
+	r.next = (r.next + 1) % capacity
+}
+
+// newestFirst returns a copy of the ring's spans, most recent first.
+func (r *ring) newestFirst() []recorder.RawSpan {
+	rval := make([]recorder.RawSpan, 0, len(r.spans))
+	for i := len(r.spans) - 1; i >= 0; i-- {
+		rval = append(rval, r.spans[(r.next+i)%len(r.spans)])
+	}
+	return rval
+}
+
+// New returns an empty Handler configured by `opts`.
+func New(opts Options) *Handler {
+	if opts.LatencyBounds == nil {
+		opts.LatencyBounds = DefaultLatencyBounds
+	}
+	if opts.SpansPerBucket <= 0 {
+		opts.SpansPerBucket = 10
+	}
+	if opts.MaxActiveSpans <= 0 {
+		opts.MaxActiveSpans = 1000
+	}
+	if opts.IsError == nil {
+		opts.IsError = spanmetrics.IsError
+	}
+	return &Handler{
+		options:    opts,
+		active:     make(map[uint64]basictracer.Span),
+		operations: make(map[string]*operation),
+	}
+}
+
+// OnStart belongs to the processor.SpanProcessor interface. It tracks `sp`
+// as active until it finishes.
+func (h *Handler) OnStart(sp basictracer.Span) {
+	ctx := sp.Context()
+	if !ctx.Sampled {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.active) >= h.options.MaxActiveSpans {
+		h.pruneLocked()
+	}
+	if len(h.active) < h.options.MaxActiveSpans {
+		h.active[ctx.SpanID] = sp
+	}
+}
+
+// pruneLocked stops tracking the active spans that will never reach
+// OnFinish.
+func (h *Handler) pruneLocked() {
+	for id, sp := range h.active {
+		if !opentracing.IsRecording(sp) {
+			delete(h.active, id)
+		}
+	}
+}
+
+// OnFinish belongs to the processor.SpanProcessor interface. It never drops
+// `span`.
+func (h *Handler) OnFinish(span *recorder.RawSpan) bool {
+	isErr := h.options.IsError(span)
+	bucket := sort.Search(len(h.options.LatencyBounds), func(i int) bool {
+		return h.options.LatencyBounds[i] > span.Duration
+	}) - 1
+	if bucket < 0 {
+		bucket = 0
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	delete(h.active, span.Context.SpanID)
+	op, ok := h.operations[span.Operation]
+	if !ok {
+		op = &operation{latency: make([]ring, len(h.options.LatencyBounds))}
+		h.operations[span.Operation] = op
+	}
+	op.latency[bucket].add(*span, h.options.SpansPerBucket)
+	if isErr {
+		op.errors.add(*span, h.options.SpansPerBucket)
+	}
+	return true
+}
+
+// RecordSpan belongs to the recorder.SpanRecorder interface, for use without
+// a processor.Pipeline. Active spans are only tracked through OnStart.
+func (h *Handler) RecordSpan(span recorder.RawSpan) {
+	h.OnFinish(&span)
+}
+
+// ServeHTTP belongs to the http.Handler interface. Without query parameters
+// it renders a summary per operation name; `op` together with `bucket`
+// (a latency bucket index), `errors` or `active` selects the spans to show.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	page := h.page(req)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := pageTemplate.Execute(w, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,111 @@
+package tracez
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/opentracing/opentracing-go/processor"
+	"github.com/opentracing/opentracing-go/recorder"
+)
+
+func newTracer(h *Handler) opentracing.Tracer {
+	pipeline := processor.NewPipeline(nil, h)
+	opts := basictracer.DefaultOptions()
+	opts.Recorder = pipeline
+	opts.OnStart = pipeline.OnStart
+	return basictracer.NewWithOptions(opts)
+}
+
+func get(t *testing.T, h *Handler, query string) string {
+	req, err := http.NewRequest("GET", "/debug/tracez"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	body, _ := ioutil.ReadAll(w.Body)
+	if w.Code != 200 {
+		t.Fatalf("GET %v: %v %s", query, w.Code, body)
+	}
+	return string(body)
+}
+
+func TestHandler(t *testing.T) {
+	h := New(Options{})
+	tracer := newTracer(h)
+
+	inflight := tracer.StartSpan("inflight")
+	root := tracer.StartSpan("GetFeed")
+	child := opentracing.StartChildSpan(root, "query")
+	child.SetTag("db.statement", "SELECT <1>")
+	child.LogEvent("retry")
+	ext.Error.Set(child, true)
+	child.FinishWithOptions(opentracing.FinishOptions{FinishTime: time.Now().Add(2 * time.Millisecond)})
+	root.Finish()
+
+	summary := get(t, h, "")
+	for _, s := range []string{"GetFeed", "query", "inflight", `?op=inflight&amp;active">1<`, `?op=query&amp;errors">1<`, `?op=query&amp;bucket=3">1<`} {
+		if !strings.Contains(summary, s) {
+			t.Errorf("Summary is missing %q:\n%s", s, summary)
+		}
+	}
+
+	active := get(t, h, "?op=inflight&active")
+	ctx := inflight.(basictracer.Span).Context()
+	if !strings.Contains(active, fmt.Sprintf("%016x", ctx.SpanID)) {
+		t.Errorf("Active spans page is missing the in-flight span:\n%s", active)
+	}
+
+	errors := get(t, h, "?op=query&errors")
+	for _, s := range []string{
+		"trace " + child.(basictracer.Span).Context().TraceID.String(),
+		`<li class="selected"><span>query`,
+		"db.statement=SELECT &lt;1&gt;",
+		" retry</div>",
+	} {
+		if !strings.Contains(errors, s) {
+			t.Errorf("Error spans page is missing %q:\n%s", s, errors)
+		}
+	}
+	if strings.Index(errors, "GetFeed") > strings.Index(errors, `<li class="selected"><span>query`) {
+		t.Errorf("Expected the child to be rendered under its parent:\n%s", errors)
+	}
+
+	inflight.Finish()
+	if strings.Contains(get(t, h, "?active"), fmt.Sprintf("%016x", ctx.SpanID)) {
+		t.Error("Finished span is still active")
+	}
+}
+
+func TestUnsampledActiveSpansArePruned(t *testing.T) {
+	h := New(Options{MaxActiveSpans: 1})
+	tracer := newTracer(h)
+
+	dropped := tracer.StartSpan("dropped")
+	ext.SamplingPriority.Set(dropped, 0)
+	dropped.Finish()
+	sp := tracer.StartSpan("inflight")
+	if !strings.Contains(get(t, h, ""), `?op=inflight&amp;active">1<`) {
+		t.Error("Expected the unsampled span to make room for the new one")
+	}
+	sp.Finish()
+}
+
+func TestRing(t *testing.T) {
+	h := New(Options{SpansPerBucket: 2})
+	for i := 1; i <= 3; i++ {
+		h.RecordSpan(recorder.RawSpan{Operation: "op", Context: recorder.SpanContext{SpanID: uint64(i)}})
+	}
+	spans := h.operations["op"].latency[0].newestFirst()
+	if len(spans) != 2 || spans[0].Context.SpanID != 3 || spans[1].Context.SpanID != 2 {
+		t.Errorf("Unexpected ring contents %+v", spans)
+	}
+}
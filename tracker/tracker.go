@@ -0,0 +1,166 @@
+// Package tracker detects leaked Spans: Spans that are started but never
+// finished, which produce missing spans and leak memory in buffering
+// Tracers.
+//
+// A Tracker records every started-but-unfinished Span together with the
+// stack that created it. Spans can be tracked explicitly with Track and
+// Untrack, or automatically by wrapping a Tracer:
+//
+//	tr := tracker.New()
+//	tracer := tr.Wrap(inner)
+//	...
+//	for _, sp := range tr.Active() {
+//	    log.Printf("unfinished span %v started at\n%s", sp.Operation, sp.Stack)
+//	}
+//
+// In tests, Verify fails the test if Spans are still open. mocktracer keeps
+// a Tracker for every MockTracer.
+package tracker
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// maxStackDepth bounds the number of frames recorded per Span.
+const maxStackDepth = 32
+
+// ActiveSpan describes a Span that has been started but not finished.
+type ActiveSpan struct {
+	Span      opentracing.Span
+	Operation string
+	Started   time.Time
+
+	// Stack is the formatted stack trace of the goroutine that started the
+	// Span, in the format of runtime/debug.Stack() without the goroutine
+	// header.
+	Stack string
+}
+
+type entry struct {
+	operation string
+	started   time.Time
+	pcs       []uintptr
+}
+
+// Tracker records active Spans. It is safe for concurrent use.
+type Tracker struct {
+	lock  sync.Mutex
+	spans map[opentracing.Span]*entry
+}
+
+// New returns an empty Tracker.
+func New() *Tracker {
+	return &Tracker{spans: make(map[opentracing.Span]*entry)}
+}
+
+// Track records `sp` as active, along with the current stack.
+func (t *Tracker) Track(sp opentracing.Span, operationName string) {
+	pcs := make([]uintptr, maxStackDepth)
+	// Skip runtime.Callers and Track.
+	pcs = pcs[:runtime.Callers(2, pcs)]
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.spans[sp] = &entry{operation: operationName, started: time.Now(), pcs: pcs}
+}
+
+// Untrack records that `sp` finished. Untracking an unknown Span is a no-op.
+func (t *Tracker) Untrack(sp opentracing.Span) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.spans, sp)
+}
+
+// SetOperationName updates the operation name reported for `sp`, if it is
+// active.
+func (t *Tracker) SetOperationName(sp opentracing.Span, operationName string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if e, ok := t.spans[sp]; ok {
+		e.operation = operationName
+	}
+}
+
+// Active returns the Spans that are currently active, oldest first.
+func (t *Tracker) Active() []ActiveSpan {
+	t.lock.Lock()
+	rval := make([]ActiveSpan, 0, len(t.spans))
+	entries := make([]*entry, 0, len(t.spans))
+	for sp, e := range t.spans {
+		rval = append(rval, ActiveSpan{Span: sp, Operation: e.operation, Started: e.started})
+		entries = append(entries, e)
+	}
+	t.lock.Unlock()
+
+	for i, e := range entries {
+		rval[i].Stack = formatStack(e.pcs)
+	}
+	sort.Stable(byStarted(rval))
+	return rval
+}
+
+type byStarted []ActiveSpan
+
+func (s byStarted) Len() int           { return len(s) }
+func (s byStarted) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byStarted) Less(i, j int) bool { return s[i].Started.Before(s[j].Started) }
+
+// TB is the subset of testing.TB used by Verify, so that this package does
+// not depend on the testing package. If `tb` also has a Helper() method, as
+// testing.TB does since Go 1.9, Verify calls it.
+type TB interface {
+	Errorf(format string, args ...interface{})
+}
+
+// Verify fails `tb` with a description (including the creation stack) of
+// every Span that is still active.
+func (t *Tracker) Verify(tb TB) {
+	if h, ok := tb.(interface {
+		Helper()
+	}); ok {
+		h.Helper()
+	}
+	active := t.Active()
+	if len(active) == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%d span(s) were not finished:", len(active))
+	for _, sp := range active {
+		fmt.Fprintf(&buf, "\n\n%q started %v ago at:\n%s", sp.Operation, time.Since(sp.Started), sp.Stack)
+	}
+	tb.Errorf("%s", buf.String())
+}
+
+func formatStack(pcs []uintptr) string {
+	var buf bytes.Buffer
+	for _, pc := range pcs {
+		// pc is a return address: look up the call instruction instead.
+		fn := runtime.FuncForPC(pc - 1)
+		if fn == nil {
+			continue
+		}
+		file, line := fn.FileLine(pc - 1)
+		if !isMachinery(fn.Name(), file) {
+			fmt.Fprintf(&buf, "%s()\n\t%s:%d\n", fn.Name(), file, line)
+		}
+	}
+	return buf.String()
+}
+
+// isMachinery returns true for the frames of the packages that start Spans
+// on behalf of the code under test, which would only clutter the stacks.
+func isMachinery(function, file string) bool {
+	if strings.HasSuffix(file, "_test.go") {
+		return false
+	}
+	return strings.Contains(function, "opentracing-go/tracker.") ||
+		strings.Contains(function, "opentracing-go/mocktracer.")
+}
@@ -0,0 +1,73 @@
+package tracker_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/internal/tbtest"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/opentracing/opentracing-go/recorder"
+	"github.com/opentracing/opentracing-go/tracker"
+)
+
+func startLeakySpan(tracer opentracing.Tracer) opentracing.Span {
+	return tracer.StartSpan("leaky")
+}
+
+func TestWrap(t *testing.T) {
+	tr := tracker.New()
+	tracer := tr.Wrap(basictracer.New(recorder.NewInMemoryRecorder()))
+
+	tracer.StartSpan("finished").Finish()
+	leaky := startLeakySpan(tracer)
+	opentracing.StartChildSpan(leaky, "child").FinishWithOptions(opentracing.FinishOptions{})
+
+	active := tr.Active()
+	if len(active) != 1 || active[0].Span != leaky || active[0].Operation != "leaky" {
+		t.Fatalf("Unexpected active spans %+v", active)
+	}
+	if !strings.HasPrefix(active[0].Stack, "github.com/opentracing/opentracing-go/tracker_test.startLeakySpan()\n") {
+		t.Errorf("Unexpected stack:\n%s", active[0].Stack)
+	}
+
+	tb := &tbtest.Recorder{}
+	tr.Verify(tb)
+	if len(tb.Errors) != 1 || !strings.Contains(tb.Errors[0], `1 span(s) were not finished:`) ||
+		!strings.Contains(tb.Errors[0], "startLeakySpan") {
+		t.Errorf("Unexpected Verify failures %q", tb.Errors)
+	}
+
+	leaky.Finish()
+	tr.Verify(t)
+}
+
+func TestMockTracer(t *testing.T) {
+	tracer := mocktracer.New()
+	sp := tracer.StartSpan("a")
+	sp.SetOperationName("b")
+	carrier := opentracing.HTTPHeaderTextMapCarrier(http.Header{})
+	if err := tracer.Inject(sp, opentracing.TextMap, carrier); err != nil {
+		t.Fatal(err)
+	}
+	joined, err := tracer.Join("c", opentracing.TextMap, carrier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined.Finish()
+
+	active := tracer.ActiveSpans()
+	if len(active) != 1 || active[0] != sp {
+		t.Fatalf("Unexpected active spans %+v", active)
+	}
+	if op := tracer.Tracker().Active()[0].Operation; op != "b" {
+		t.Errorf("Expected the renamed operation, got %q", op)
+	}
+	if stack := tracer.Tracker().Active()[0].Stack; !strings.HasPrefix(stack, "github.com/opentracing/opentracing-go/tracker_test.TestMockTracer()\n") {
+		t.Errorf("Unexpected stack:\n%s", stack)
+	}
+	sp.Finish()
+	tracer.Tracker().Verify(t)
+}
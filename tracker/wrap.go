@@ -0,0 +1,110 @@
+package tracker
+
+import (
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Wrap returns a Tracer that forwards to `tracer`, and Tracks every Span it
+// starts (or joins) until it finishes.
+func (t *Tracker) Wrap(tracer opentracing.Tracer) opentracing.Tracer {
+	return &trackingTracer{tracer: tracer, tracker: t}
+}
+
+// Unwrap returns the Span wrapped by a tracking Span, or `sp` itself if it
+// was not created by a Tracer returned by Wrap.
+func Unwrap(sp opentracing.Span) opentracing.Span {
+	if ts, ok := sp.(*trackingSpan); ok {
+		return ts.span
+	}
+	return sp
+}
+
+type trackingTracer struct {
+	tracer  opentracing.Tracer
+	tracker *Tracker
+}
+
+func (t *trackingTracer) StartSpan(operationName string) opentracing.Span {
+	return t.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: operationName,
+	})
+}
+
+func (t *trackingTracer) StartSpanWithOptions(opts opentracing.StartSpanOptions) opentracing.Span {
+	opts.Parent = Unwrap(opts.Parent)
+	return t.track(t.tracer.StartSpanWithOptions(opts), opts.OperationName)
+}
+
+func (t *trackingTracer) track(span opentracing.Span, operationName string) opentracing.Span {
+	sp := &trackingSpan{tracer: t, span: span}
+	t.tracker.Track(sp, operationName)
+	return sp
+}
+
+func (t *trackingTracer) Inject(sp opentracing.Span, format interface{}, carrier interface{}) error {
+	return t.tracer.Inject(Unwrap(sp), format, carrier)
+}
+
+func (t *trackingTracer) Join(operationName string, format interface{}, carrier interface{}) (opentracing.Span, error) {
+	span, err := t.tracer.Join(operationName, format, carrier)
+	if err != nil {
+		return nil, err
+	}
+	return t.track(span, operationName), nil
+}
+
+// trackingSpan Untracks itself when it finishes.
+type trackingSpan struct {
+	tracer *trackingTracer
+	span   opentracing.Span
+}
+
+func (s *trackingSpan) SetOperationName(operationName string) opentracing.Span {
+	s.tracer.tracker.SetOperationName(s, operationName)
+	s.span.SetOperationName(operationName)
+	return s
+}
+
+func (s *trackingSpan) SetTag(key string, value interface{}) opentracing.Span {
+	s.span.SetTag(key, value)
+	return s
+}
+
+func (s *trackingSpan) Finish() {
+	s.tracer.tracker.Untrack(s)
+	s.span.Finish()
+}
+
+func (s *trackingSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	s.tracer.tracker.Untrack(s)
+	s.span.FinishWithOptions(opts)
+}
+
+func (s *trackingSpan) LogEvent(event string) {
+	s.span.LogEvent(event)
+}
+
+func (s *trackingSpan) LogEventWithPayload(event string, payload interface{}) {
+	s.span.LogEventWithPayload(event, payload)
+}
+
+func (s *trackingSpan) Log(data opentracing.LogData) {
+	s.span.Log(data)
+}
+
+func (s *trackingSpan) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.span.SetBaggageItem(restrictedKey, value)
+	return s
+}
+
+func (s *trackingSpan) BaggageItem(restrictedKey string) string {
+	return s.span.BaggageItem(restrictedKey)
+}
+
+func (s *trackingSpan) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *trackingSpan) IsRecording() bool {
+	return opentracing.IsRecording(s.span)
+}
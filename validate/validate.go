@@ -0,0 +1,183 @@
+// Package validate detects violations of the opentracing.Span contract that
+// implementations are otherwise free to leave undefined:
+//
+//   - calling Finish() or FinishWithOptions() more than once;
+//   - using a Span after it finished;
+//   - a FinishOptions.FinishTime earlier than the Span's start;
+//   - BulkLogData with zero timestamps or timestamps outside of the Span's
+//     [start, finish] range.
+//
+// Wrap a Tracer to check every Span it creates:
+//
+//	v := validate.New(validate.Options{})
+//	tracer := v.Wrap(inner)
+//	...
+//	for _, violation := range v.Violations() {
+//	    t.Error(violation, "\n", violation.Stack)
+//	}
+//
+// mocktracer offers the same checks through its Strict() option.
+package validate
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Kind classifies Violations.
+type Kind int
+
+const (
+	// DoubleFinish is reported when a Span is finished more than once.
+	DoubleFinish Kind = iota
+	// UseAfterFinish is reported when a finished Span is used.
+	UseAfterFinish
+	// FinishBeforeStart is reported when FinishOptions.FinishTime is before
+	// the start of the Span.
+	FinishBeforeStart
+	// ZeroLogTimestamp is reported for BulkLogData with a zero Timestamp.
+	ZeroLogTimestamp
+	// LogTimestampOutOfRange is reported for BulkLogData with a Timestamp
+	// outside of the Span's [start, finish] range.
+	LogTimestampOutOfRange
+)
+
+func (k Kind) String() string {
+	switch k {
+	case DoubleFinish:
+		return "double finish"
+	case UseAfterFinish:
+		return "use after finish"
+	case FinishBeforeStart:
+		return "finish before start"
+	case ZeroLogTimestamp:
+		return "zero log timestamp"
+	case LogTimestampOutOfRange:
+		return "log timestamp out of range"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Violation describes one misuse of a Span.
+type Violation struct {
+	Kind      Kind
+	Operation string
+
+	// Method is the name of the offending Span (or Tracer) method.
+	Method string
+
+	// Detail is a human readable description; may be empty.
+	Detail string
+
+	// Stack is the formatted stack trace of the offending call.
+	Stack string
+}
+
+func (v Violation) Error() string {
+	msg := fmt.Sprintf("%v: %v on span %q", v.Kind, v.Method, v.Operation)
+	if v.Detail != "" {
+		msg += ": " + v.Detail
+	}
+	return msg
+}
+
+// Options configures a Validator.
+type Options struct {
+	// OnViolation, if non-nil, is called with every Violation as it is
+	// reported, e.g. to panic or log.
+	OnViolation func(v Violation)
+}
+
+// Validator collects Violations. It is safe for concurrent use.
+type Validator struct {
+	options Options
+
+	lock       sync.Mutex
+	violations []Violation
+}
+
+// New returns a Validator without Violations.
+func New(opts Options) *Validator {
+	return &Validator{options: opts}
+}
+
+// Report records a Violation of kind `kind`, along with the stack of the
+// caller.
+func (v *Validator) Report(kind Kind, operation, method, detail string) {
+	violation := Violation{
+		Kind:      kind,
+		Operation: operation,
+		Method:    method,
+		Detail:    detail,
+		Stack:     stack(),
+	}
+	v.lock.Lock()
+	v.violations = append(v.violations, violation)
+	v.lock.Unlock()
+	if v.options.OnViolation != nil {
+		v.options.OnViolation(violation)
+	}
+}
+
+// CheckFinish reports the Violations in `opts` for a Span started at
+// `start`. A zero FinishTime stands for time.Now().
+func (v *Validator) CheckFinish(operation string, start time.Time, opts opentracing.FinishOptions) {
+	finish := opts.FinishTime
+	if finish.IsZero() {
+		finish = time.Now()
+	} else if finish.Before(start) {
+		v.Report(FinishBeforeStart, operation, "FinishWithOptions",
+			fmt.Sprintf("FinishTime %v is before start %v", finish, start))
+	}
+	for i, ld := range opts.BulkLogData {
+		switch {
+		case ld.Timestamp.IsZero():
+			v.Report(ZeroLogTimestamp, operation, "FinishWithOptions",
+				fmt.Sprintf("BulkLogData[%d] (%q)", i, ld.Event))
+		case ld.Timestamp.Before(start) || ld.Timestamp.After(finish):
+			v.Report(LogTimestampOutOfRange, operation, "FinishWithOptions",
+				fmt.Sprintf("BulkLogData[%d] (%q) timestamp %v is not within [%v, %v]", i, ld.Event, ld.Timestamp, start, finish))
+		}
+	}
+}
+
+// Violations returns a copy of the Violations reported so far.
+func (v *Validator) Violations() []Violation {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return append([]Violation(nil), v.violations...)
+}
+
+// Reset discards the Violations reported so far.
+func (v *Validator) Reset() {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	v.violations = nil
+}
+
+// stack formats the stack of the goroutine, leaving out the frames of this
+// package.
+func stack() string {
+	pcs := make([]uintptr, 32)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+	var buf bytes.Buffer
+	for _, pc := range pcs {
+		// pc is a return address: look up the call instruction instead.
+		fn := runtime.FuncForPC(pc - 1)
+		if fn == nil {
+			continue
+		}
+		name := fn.Name()
+		if !strings.Contains(name, "opentracing-go/validate.") && !strings.HasPrefix(name, "runtime.") {
+			file, line := fn.FileLine(pc - 1)
+			fmt.Fprintf(&buf, "%s()\n\t%s:%d\n", name, file, line)
+		}
+	}
+	return buf.String()
+}
@@ -0,0 +1,104 @@
+package validate_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/basictracer"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	"github.com/opentracing/opentracing-go/recorder"
+	"github.com/opentracing/opentracing-go/validate"
+)
+
+func misuse(tracer opentracing.Tracer) {
+	start := time.Now()
+	sp := tracer.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: "op",
+		StartTime:     start,
+	})
+	sp.FinishWithOptions(opentracing.FinishOptions{
+		FinishTime: start.Add(-time.Second),
+		BulkLogData: []opentracing.LogData{
+			{Event: "zero"},
+			{Event: "late", Timestamp: start.Add(time.Hour)},
+		},
+	})
+	sp.SetTag("k", "v")
+	sp.Finish()
+}
+
+func kinds(violations []validate.Violation) []validate.Kind {
+	rval := make([]validate.Kind, len(violations))
+	for i, v := range violations {
+		rval[i] = v.Kind
+	}
+	return rval
+}
+
+var expectedKinds = []validate.Kind{
+	validate.FinishBeforeStart,
+	validate.ZeroLogTimestamp,
+	validate.LogTimestampOutOfRange,
+	validate.UseAfterFinish,
+	validate.DoubleFinish,
+}
+
+func checkKinds(t *testing.T, violations []validate.Violation) {
+	got := kinds(violations)
+	if len(got) != len(expectedKinds) {
+		t.Fatalf("Unexpected violations %v", violations)
+	}
+	for i := range got {
+		if got[i] != expectedKinds[i] {
+			t.Errorf("Violation %d is %v, expected %v", i, got[i], expectedKinds[i])
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	rec := recorder.NewInMemoryRecorder()
+	var reported int
+	v := validate.New(validate.Options{OnViolation: func(validate.Violation) { reported++ }})
+	misuse(v.Wrap(basictracer.New(rec)))
+
+	violations := v.Violations()
+	checkKinds(t, violations)
+	if reported != len(violations) {
+		t.Errorf("OnViolation was called %d times", reported)
+	}
+	if !strings.HasPrefix(violations[3].Stack, "github.com/opentracing/opentracing-go/validate_test.misuse()\n") {
+		t.Errorf("Unexpected stack:\n%s", violations[3].Stack)
+	}
+	if msg := violations[3].Error(); msg != `use after finish: SetTag on span "op"` {
+		t.Errorf("Unexpected message %q", msg)
+	}
+	if len(rec.GetSpans()) != 1 {
+		t.Errorf("Expected the span to be recorded once, got %v", rec.GetSpans())
+	}
+
+	v.Reset()
+	tracer := v.Wrap(basictracer.New(rec))
+	parent := tracer.StartSpan("parent")
+	opentracing.StartChildSpan(parent, "child").Finish()
+	parent.Finish()
+	if violations := v.Violations(); len(violations) != 0 {
+		t.Errorf("Unexpected violations %v", violations)
+	}
+}
+
+func TestMockTracerStrict(t *testing.T) {
+	tracer := mocktracer.New(mocktracer.Strict())
+	misuse(tracer)
+	checkKinds(t, tracer.Violations())
+	if len(tracer.FinishedSpans) != 1 {
+		t.Errorf("Expected the span to be finished once, got %v", tracer.FinishedSpans)
+	}
+
+	lax := mocktracer.New()
+	misuse(lax)
+	if lax.Violations() != nil || len(lax.FinishedSpans) != 2 {
+		t.Errorf("Unexpected non-strict behavior")
+	}
+}
@@ -0,0 +1,167 @@
+package validate
+
+import (
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// Wrap returns a Tracer that forwards to `tracer` and reports the
+// Violations of the Spans it creates to `v`. Calls on finished Spans are
+// still forwarded; the second Finish() of a Span is not.
+func (v *Validator) Wrap(tracer opentracing.Tracer) opentracing.Tracer {
+	return &validatingTracer{tracer: tracer, validator: v}
+}
+
+// Unwrap returns the Span wrapped by a validating Span, or `sp` itself if it
+// was not created by a Tracer returned by Wrap.
+func Unwrap(sp opentracing.Span) opentracing.Span {
+	if vs, ok := sp.(*validatingSpan); ok {
+		return vs.span
+	}
+	return sp
+}
+
+type validatingTracer struct {
+	tracer    opentracing.Tracer
+	validator *Validator
+}
+
+func (t *validatingTracer) StartSpan(operationName string) opentracing.Span {
+	return t.StartSpanWithOptions(opentracing.StartSpanOptions{
+		OperationName: operationName,
+	})
+}
+
+func (t *validatingTracer) StartSpanWithOptions(opts opentracing.StartSpanOptions) opentracing.Span {
+	if opts.StartTime.IsZero() {
+		opts.StartTime = time.Now()
+	}
+	start := opts.StartTime
+	opts.Parent = Unwrap(opts.Parent)
+	return t.wrap(t.tracer.StartSpanWithOptions(opts), opts.OperationName, start)
+}
+
+func (t *validatingTracer) wrap(span opentracing.Span, operationName string, start time.Time) opentracing.Span {
+	return &validatingSpan{tracer: t, span: span, operation: operationName, start: start}
+}
+
+func (t *validatingTracer) Inject(sp opentracing.Span, format interface{}, carrier interface{}) error {
+	if vs, ok := sp.(*validatingSpan); ok {
+		vs.check("Inject")
+	}
+	return t.tracer.Inject(Unwrap(sp), format, carrier)
+}
+
+func (t *validatingTracer) Join(operationName string, format interface{}, carrier interface{}) (opentracing.Span, error) {
+	start := time.Now()
+	span, err := t.tracer.Join(operationName, format, carrier)
+	if err != nil {
+		return nil, err
+	}
+	return t.wrap(span, operationName, start), nil
+}
+
+// validatingSpan checks the calls made to the Span it wraps.
+type validatingSpan struct {
+	tracer *validatingTracer
+	span   opentracing.Span
+	start  time.Time
+
+	lock      sync.Mutex
+	operation string
+	finished  bool
+}
+
+// check reports a UseAfterFinish Violation if the Span has finished, and
+// returns false in that case.
+func (s *validatingSpan) check(method string) bool {
+	s.lock.Lock()
+	finished, operation := s.finished, s.operation
+	s.lock.Unlock()
+	if finished {
+		s.tracer.validator.Report(UseAfterFinish, operation, method, "")
+	}
+	return !finished
+}
+
+// finish marks the Span finished, reporting a DoubleFinish Violation and
+// returning false if it already was.
+func (s *validatingSpan) finish(method string) bool {
+	s.lock.Lock()
+	finished, operation := s.finished, s.operation
+	s.finished = true
+	s.lock.Unlock()
+	if finished {
+		s.tracer.validator.Report(DoubleFinish, operation, method, "")
+	}
+	return !finished
+}
+
+func (s *validatingSpan) SetOperationName(operationName string) opentracing.Span {
+	s.check("SetOperationName")
+	s.lock.Lock()
+	s.operation = operationName
+	s.lock.Unlock()
+	s.span.SetOperationName(operationName)
+	return s
+}
+
+func (s *validatingSpan) SetTag(key string, value interface{}) opentracing.Span {
+	s.check("SetTag")
+	s.span.SetTag(key, value)
+	return s
+}
+
+func (s *validatingSpan) Finish() {
+	if s.finish("Finish") {
+		s.span.Finish()
+	}
+}
+
+func (s *validatingSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	if !s.finish("FinishWithOptions") {
+		return
+	}
+	s.lock.Lock()
+	operation := s.operation
+	s.lock.Unlock()
+	s.tracer.validator.CheckFinish(operation, s.start, opts)
+	s.span.FinishWithOptions(opts)
+}
+
+func (s *validatingSpan) LogEvent(event string) {
+	s.check("LogEvent")
+	s.span.LogEvent(event)
+}
+
+func (s *validatingSpan) LogEventWithPayload(event string, payload interface{}) {
+	s.check("LogEventWithPayload")
+	s.span.LogEventWithPayload(event, payload)
+}
+
+func (s *validatingSpan) Log(data opentracing.LogData) {
+	s.check("Log")
+	s.span.Log(data)
+}
+
+func (s *validatingSpan) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	s.check("SetBaggageItem")
+	s.span.SetBaggageItem(restrictedKey, value)
+	return s
+}
+
+func (s *validatingSpan) BaggageItem(restrictedKey string) string {
+	s.check("BaggageItem")
+	return s.span.BaggageItem(restrictedKey)
+}
+
+func (s *validatingSpan) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+// IsRecording may be called after Finish(), so it is not checked.
+func (s *validatingSpan) IsRecording() bool {
+	return opentracing.IsRecording(s.span)
+}